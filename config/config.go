@@ -10,7 +10,78 @@ type ConnectionInfo struct {
 	Schemas  []string `json:"schemas"`
 }
 
+// TypeOverride replaces the type generated for a column, as in
+// {"go_type": "decimal.Decimal", "go_import": "github.com/shopspring/decimal"}.
+// The python type is imported as a module when it is qualified by it, and
+// from the module otherwise.
+type TypeOverride struct {
+	GoType       string `json:"go_type,omitempty"`
+	GoImport     string `json:"go_import,omitempty"`
+	PythonType   string `json:"python_type,omitempty"`
+	PythonImport string `json:"python_import,omitempty"`
+}
+
+// Overrides maps database types, and columns given as "schema.table.column"
+// or "table.column", to the types generated for them. Overridden json
+// columns are marshalled from and unmarshalled into their type.
+type Overrides struct {
+	Types   map[string]TypeOverride `json:"types"`
+	Columns map[string]TypeOverride `json:"columns"`
+}
+
 type Config struct {
-	Language string         `json:"language"`
-	ConnInfo ConnectionInfo `json:"connection"`
+	Language  string         `json:"language"`
+	ConnInfo  ConnectionInfo `json:"connection"`
+	DDLFile   string         `json:"ddl_file"`
+	Snapshot  bool           `json:"snapshot"`
+	Overrides Overrides      `json:"overrides"`
+	// SortKeys maps tables, given as "schema.table" or "table", to the columns
+	// their rows are ordered and paged by. The columns must be unique together
+	// and not null; tables default to their primary key.
+	SortKeys map[string][]string `json:"sort_keys,omitempty"`
+}
+
+// SearchSortKey returns the configured sort key of a table, nil if it has none
+func (c *Config) SearchSortKey(schema string, table string) []string {
+	if columns, exists := c.SortKeys[schema+"."+table]; exists {
+		return columns
+	}
+	if columns, exists := c.SortKeys[table]; exists {
+		return columns
+	}
+	return nil
+}
+
+// SearchColumnOverride returns the override of a column, nil if it has none
+func (c *Config) SearchColumnOverride(schema string, table string, column string) *TypeOverride {
+	if override, exists := c.Overrides.Columns[schema+"."+table+"."+column]; exists {
+		return &override
+	}
+	if override, exists := c.Overrides.Columns[table+"."+column]; exists {
+		return &override
+	}
+	return nil
+}
+
+// SearchTypeOverride returns the override of the first of the given names of
+// a database type that has one, nil if none has
+func (c *Config) SearchTypeOverride(names ...string) *TypeOverride {
+	for i := range names {
+		if override, exists := c.Overrides.Types[names[i]]; exists {
+			return &override
+		}
+	}
+	return nil
+}
+
+// AllOverrides lists the type and column overrides
+func (c *Config) AllOverrides() []TypeOverride {
+	overrides := make([]TypeOverride, 0)
+	for _, override := range c.Overrides.Types {
+		overrides = append(overrides, override)
+	}
+	for _, override := range c.Overrides.Columns {
+		overrides = append(overrides, override)
+	}
+	return overrides
 }
@@ -0,0 +1,1316 @@
+package ddl
+
+import (
+	"dto-gen/config"
+	"dto-gen/metadata"
+	"dto-gen/pgsql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ======================================================================================
+//     Parser
+// ======================================================================================
+
+type parser struct {
+	src    string
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() *Token {
+	if p.done() {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) next() *Token {
+	tok := p.peek()
+	if tok != nil {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the given sequence of keywords/symbols if the input
+// continues with it, and reports whether it did
+func (p *parser) accept(words ...string) bool {
+	if p.pos+len(words) > len(p.tokens) {
+		return false
+	}
+	for i := range words {
+		if !p.tokens[p.pos+i].is(words[i]) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.errorf("expected %s", strings.Join(words, " "))
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if tok := p.peek(); tok != nil {
+		return fmt.Errorf("%s near \"%s\" (line %d)", msg, tok.Text, strings.Count(p.src[:tok.Start], "\n")+1)
+	}
+	return fmt.Errorf("%s at end of statement", msg)
+}
+
+func (p *parser) identifier() (string, error) {
+	tok := p.peek()
+	if tok == nil || (tok.Kind != TokenIdent && tok.Kind != TokenQuotedIdent) {
+		return "", p.errorf("expected identifier")
+	}
+	p.next()
+	return tok.Text, nil
+}
+
+// qualifiedName reads "name" or "schema.name", defaulting the schema to public
+func (p *parser) qualifiedName() (string, string, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", "", err
+	}
+	if p.accept(".") {
+		table, err := p.identifier()
+		if err != nil {
+			return "", "", err
+		}
+		return name, table, nil
+	}
+	return "public", name, nil
+}
+
+// identList reads a parenthesized, comma separated list of identifiers
+func (p *parser) identList() ([]string, error) {
+	err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if p.accept(")") {
+			return names, nil
+		}
+		err = p.expect(",")
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipParens skips a balanced parenthesized group, the parser being on "("
+func (p *parser) skipParens() error {
+	depth := 0
+	for !p.done() {
+		tok := p.next()
+		if tok.is("(") {
+			depth++
+		} else if tok.is(")") {
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf("unbalanced parenthesis")
+}
+
+// rest returns the tokens not consumed yet
+func (p *parser) rest() []Token {
+	return p.tokens[p.pos:]
+}
+
+// splitTopLevel splits tokens at the commas that are not nested in parenthesis
+func splitTopLevel(tokens []Token) [][]Token {
+	parts := make([][]Token, 0)
+	depth := 0
+	start := 0
+	for i := range tokens {
+		if tokens[i].is("(") || tokens[i].is("[") {
+			depth++
+		} else if tokens[i].is(")") || tokens[i].is("]") {
+			depth--
+		} else if tokens[i].is(",") && depth == 0 {
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// ======================================================================================
+//     Types
+// ======================================================================================
+
+// aliases of PostgreSQL types to the names reported by information_schema.columns.data_type
+var typeAliases = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"integer":                     "integer",
+	"int8":                        "bigint",
+	"bigint":                      "bigint",
+	"int2":                        "smallint",
+	"smallint":                    "smallint",
+	"varchar":                     "character varying",
+	"character varying":           "character varying",
+	"char varying":                "character varying",
+	"char":                        "character",
+	"character":                   "character",
+	"bpchar":                      "character",
+	"bool":                        "boolean",
+	"boolean":                     "boolean",
+	"float":                       "double precision",
+	"float8":                      "double precision",
+	"double precision":            "double precision",
+	"float4":                      "real",
+	"real":                        "real",
+	"decimal":                     "numeric",
+	"numeric":                     "numeric",
+	"timestamp":                   "timestamp without time zone",
+	"timestamp without time zone": "timestamp without time zone",
+	"timestamptz":                 "timestamp with time zone",
+	"timestamp with time zone":    "timestamp with time zone",
+	"time":                        "time without time zone",
+	"time without time zone":      "time without time zone",
+	"timetz":                      "time with time zone",
+	"time with time zone":         "time with time zone",
+	"varbit":                      "bit varying",
+	"bit varying":                 "bit varying",
+	"bit":                         "bit",
+	"bytea":                       "bytea",
+	"cidr":                        "cidr",
+	"date":                        "date",
+	"daterange":                   "daterange",
+	"inet":                        "inet",
+	"int4range":                   "int4range",
+	"int8range":                   "int8range",
+	"interval":                    "interval",
+	"json":                        "json",
+	"jsonb":                       "jsonb",
+	"macaddr":                     "macaddr",
+	"macaddr8":                    "macaddr8",
+	"money":                       "money",
+	"numrange":                    "numrange",
+	"oid":                         "oid",
+	"point":                       "point",
+	"text":                        "text",
+	"tsquery":                     "tsquery",
+	"tsrange":                     "tsrange",
+	"tstzrange":                   "tstzrange",
+	"tsvector":                    "tsvector",
+	"uuid":                        "uuid",
+	"xml":                         "xml",
+}
+
+// serial pseudo types and the integer type backing them
+var serialTypes = map[string]string{
+	"serial":      "integer",
+	"serial4":     "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+}
+
+// keywords that end the data type of a column definition
+var columnConstraintKeywords = map[string]bool{
+	"constraint": true,
+	"not":        true,
+	"null":       true,
+	"default":    true,
+	"primary":    true,
+	"references": true,
+	"unique":     true,
+	"check":      true,
+	"generated":  true,
+	"collate":    true,
+	"deferrable": true,
+	"initially":  true,
+	"using":      true,
+}
+
+// dataType reads the type of a column definition, such as "integer",
+// "character varying(40)", "timestamp(3) with time zone" or "text[]"
+func (p *parser) dataType() (string, []string, int, error) {
+	name := ""
+	modifiers := make([]string, 0)
+	dims := 0
+	for !p.done() {
+		tok := p.peek()
+		if (tok.Kind == TokenIdent && !columnConstraintKeywords[tok.Text]) || tok.Kind == TokenQuotedIdent {
+			if tok.is("array") {
+				dims++
+			} else if name == "" || strings.HasSuffix(name, ".") {
+				name += tok.Text
+			} else {
+				name += " " + tok.Text
+			}
+			p.next()
+		} else if tok.is(".") {
+			name += "."
+			p.next()
+		} else if tok.is("(") {
+			// type modifiers, as in varchar(40) or numeric(10, 2)
+			p.next()
+			for !p.done() && !p.peek().is(")") {
+				if modifier := p.next(); !modifier.is(",") {
+					modifiers = append(modifiers, modifier.Text)
+				}
+			}
+			err := p.expect(")")
+			if err != nil {
+				return "", nil, 0, err
+			}
+		} else if tok.is("[") {
+			for !p.done() && !p.next().is("]") {
+			}
+			dims++
+		} else {
+			break
+		}
+	}
+	if name == "" {
+		return "", nil, 0, p.errorf("expected data type")
+	}
+	return strings.TrimPrefix(name, "pg_catalog."), modifiers, dims, nil
+}
+
+// ======================================================================================
+//     Schema
+// ======================================================================================
+
+type ddlFkInfo struct {
+	Name             string
+	Schema           string
+	Table            string
+	Columns          []string
+	ReferencedSchema string
+	ReferencedTable  string
+	ReferencedCols   []string
+	OnDelete         string
+	OnUpdate         string
+}
+
+type schemaBuilder struct {
+	src    string
+	tables []metadata.Table
+	index  map[string]int
+	fks    []ddlFkInfo
+	enums  []metadata.Enum
+}
+
+func tableKey(schema string, name string) string {
+	return schema + "." + name
+}
+
+func (b *schemaBuilder) table(schema string, name string) *metadata.Table {
+	idx, exists := b.index[tableKey(schema, name)]
+	if !exists {
+		return nil
+	}
+	return &b.tables[idx]
+}
+
+func (b *schemaBuilder) enum(schema string, name string) *metadata.Enum {
+	for i := range b.enums {
+		if b.enums[i].Schema == schema && b.enums[i].Name == name {
+			return &b.enums[i]
+		}
+	}
+	return nil
+}
+
+// addConstraint adds a primary key or unique constraint to a table, along
+// with the index backing it, named as PostgreSQL would when the script
+// leaves it unnamed
+func (b *schemaBuilder) addConstraint(table *metadata.Table, name string, constraintType string, columns []string) error {
+	for i := range columns {
+		col := table.SearchColumnByName(columns[i])
+		if col == nil {
+			return fmt.Errorf("%s column %s not found in table %s", strings.ToLower(constraintType), columns[i], table.Name)
+		}
+		if constraintType == metadata.PrimaryKeyConstraint {
+			col.IsPrimaryKey = true
+			col.Nullable = false
+		}
+	}
+	if name == "" {
+		name = metadata.DefaultConstraintName(table.Name, constraintType, columns)
+	}
+	table.Constraints = append(table.Constraints, metadata.Constraint{
+		Name:    name,
+		Type:    constraintType,
+		Columns: columns,
+	})
+	table.Indexes = append(table.Indexes, metadata.Index{
+		Name:      name,
+		Columns:   columns,
+		IsUnique:  true,
+		IsPrimary: constraintType == metadata.PrimaryKeyConstraint,
+	})
+	return nil
+}
+
+// dropIndex removes an index by name, reporting whether the table had it
+func (b *schemaBuilder) dropIndex(table *metadata.Table, name string) bool {
+	for i := range table.Indexes {
+		if table.Indexes[i].Name == name {
+			table.Indexes = append(table.Indexes[:i], table.Indexes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// dropConstraint removes a constraint by name, foreign keys included
+func (b *schemaBuilder) dropConstraint(table *metadata.Table, name string) {
+	b.dropIndex(table, name)
+	for i := range table.Constraints {
+		if table.Constraints[i].Name != name {
+			continue
+		}
+		if table.Constraints[i].Type == metadata.PrimaryKeyConstraint {
+			for j := range table.Columns {
+				table.Columns[j].IsPrimaryKey = false
+			}
+		}
+		table.Constraints = append(table.Constraints[:i], table.Constraints[i+1:]...)
+		return
+	}
+	for i := range b.fks {
+		if b.fks[i].Schema == table.Schema && b.fks[i].Table == table.Name && b.fks[i].Name == name {
+			b.fks = append(b.fks[:i], b.fks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *schemaBuilder) parseStatement(p *parser) error {
+	if p.accept("create") {
+		p.accept("or", "replace")
+		for p.accept("global") || p.accept("local") || p.accept("temp") || p.accept("temporary") || p.accept("unlogged") {
+		}
+		if p.accept("table") {
+			return b.parseCreateTable(p)
+		}
+		if p.accept("type") {
+			return b.parseCreateType(p)
+		}
+		if p.accept("index") {
+			return b.parseCreateIndex(p, false)
+		}
+		if p.accept("unique", "index") {
+			return b.parseCreateIndex(p, true)
+		}
+		// the columns of a view take the types of its query, which can't be
+		// worked out without a database, so views get no DTO offline
+		if p.accept("view") || p.accept("materialized", "view") || p.accept("recursive", "view") {
+			p.accept("if", "not", "exists")
+			schema, name, err := p.qualifiedName()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("    skipping view %s.%s, views are only read from a live database\n", schema, name)
+		}
+		return nil
+	}
+	if p.accept("alter", "table") {
+		return b.parseAlterTable(p)
+	}
+	if p.accept("alter", "type") {
+		return b.parseAlterType(p)
+	}
+	if p.accept("drop", "index") {
+		return b.parseDropIndex(p)
+	}
+	if p.accept("comment", "on") {
+		return b.parseComment(p)
+	}
+
+	// anything else (functions, grants...) is not part of the metadata
+	return nil
+}
+
+func (b *schemaBuilder) parseCreateTable(p *parser) error {
+	p.accept("if", "not", "exists")
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.peek().is("(") {
+		// CREATE TABLE ... AS / PARTITION OF: columns come from elsewhere
+		return nil
+	}
+	if b.table(schema, name) != nil {
+		return fmt.Errorf("table %s.%s defined twice", schema, name)
+	}
+
+	// split the element list, keeping the closing parenthesis out of it
+	start := p.pos + 1
+	err = p.skipParens()
+	if err != nil {
+		return err
+	}
+	elements := splitTopLevel(p.tokens[start : p.pos-1])
+
+	b.tables = append(b.tables, metadata.Table{Schema: schema, Name: name, Columns: make([]metadata.Column, 0)})
+	b.index[tableKey(schema, name)] = len(b.tables) - 1
+
+	for i := range elements {
+		ep := &parser{src: b.src, tokens: elements[i]}
+		if isTableConstraint(ep) {
+			err = b.parseTableConstraint(ep, schema, name)
+		} else if ep.accept("like") {
+			err = ep.errorf("LIKE is not supported")
+		} else {
+			err = b.parseColumnDef(ep, schema, name)
+		}
+		if err != nil {
+			return fmt.Errorf("table %s.%s: %w", schema, name, err)
+		}
+	}
+
+	return nil
+}
+
+func isTableConstraint(p *parser) bool {
+	tok := p.peek()
+	if tok == nil {
+		return false
+	}
+	for _, keyword := range []string{"constraint", "primary", "foreign", "unique", "check", "exclude"} {
+		if tok.is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatType spells out a builtin type with its modifiers the way
+// format_type does, as in character varying(40), numeric(10,2) or
+// timestamp(3) with time zone
+func formatType(typeName string, datatype string, modifiers []string) string {
+	switch datatype {
+	case "character", "bit":
+		// char and bit hold a single character or bit unless told otherwise
+		if len(modifiers) == 0 && typeName != "bpchar" {
+			modifiers = []string{"1"}
+		}
+	case "numeric":
+		if len(modifiers) == 1 {
+			modifiers = append(modifiers, "0")
+		}
+	case "timestamp without time zone", "timestamp with time zone", "time without time zone", "time with time zone":
+		if len(modifiers) > 0 {
+			return strings.Replace(datatype, " ", "("+modifiers[0]+") ", 1)
+		}
+	case "character varying", "bit varying", "interval":
+	default:
+		return datatype
+	}
+	if len(modifiers) == 0 {
+		return datatype
+	}
+	return datatype + "(" + strings.Join(modifiers, ",") + ")"
+}
+
+// setColumnType fills the type of a column as information_schema would
+// describe it, arrays keeping their element type aside
+func setColumnType(col *metadata.Column, typeName string, modifiers []string, dims int) {
+	datatype, exists := typeAliases[typeName]
+	udtSchema := ""
+	udtName := ""
+	formattedType := ""
+	if !exists {
+		datatype = "USER-DEFINED"
+		udtSchema = "public"
+		udtName = typeName
+		if dot := strings.LastIndex(typeName, "."); dot >= 0 {
+			udtSchema = typeName[:dot]
+			udtName = typeName[dot+1:]
+		}
+	} else if pgType := pgsql.SearchPgType(datatype); pgType != nil {
+		// builtin types are known by their udt name, as in information_schema
+		udtSchema = "pg_catalog"
+		udtName = pgType.UdtName
+		formattedType = formatType(typeName, datatype, modifiers)
+	}
+
+	col.UdtSchema = udtSchema
+	col.UdtName = udtName
+	col.FormattedType = formattedType
+	col.ElementType = ""
+	col.ArrayDims = 0
+	if dims > 0 {
+		col.Datatype = "ARRAY"
+		col.ElementType = datatype
+		col.ArrayDims = dims
+		if udtName != "" {
+			col.UdtName = "_" + udtName
+		}
+	} else {
+		col.Datatype = datatype
+	}
+}
+
+func (b *schemaBuilder) parseColumnDef(p *parser, schema string, tableName string) error {
+	name, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	typeName, modifiers, dims, err := p.dataType()
+	if err != nil {
+		return err
+	}
+
+	table := b.table(schema, tableName)
+	col := metadata.Column{
+		Ordinal:  len(table.Columns) + 1,
+		Name:     name,
+		Nullable: true,
+	}
+
+	if backing, isSerial := serialTypes[typeName]; isSerial && dims == 0 {
+		// serial columns are integers fed by an implicit sequence
+		defaultValue := fmt.Sprintf("nextval('%s_%s_seq'::regclass)", tableName, name)
+		setColumnType(&col, backing, nil, 0)
+		col.Nullable = false
+		col.IsAutoIncrement = true
+		col.DefaultValue = &defaultValue
+	} else {
+		setColumnType(&col, typeName, modifiers, dims)
+	}
+
+	isPrimaryKey := false
+	isUnique := false
+	var constraintName, primaryKeyName, uniqueName string
+	for !p.done() {
+		if p.accept("constraint") {
+			constraintName, err = p.identifier()
+			if err != nil {
+				return err
+			}
+			continue
+		} else if p.accept("not", "null") {
+			col.Nullable = false
+		} else if p.accept("null") {
+			col.Nullable = true
+		} else if p.accept("default") {
+			defaultValue := p.expression()
+			col.DefaultValue = &defaultValue
+			if strings.HasPrefix(strings.ToLower(defaultValue), "nextval(") {
+				col.IsAutoIncrement = true
+			}
+		} else if p.accept("primary", "key") {
+			isPrimaryKey = true
+			primaryKeyName = constraintName
+		} else if p.accept("unique") {
+			isUnique = true
+			uniqueName = constraintName
+			if p.accept("nulls") {
+				p.accept("not")
+				err = p.expect("distinct")
+			}
+		} else if p.accept("references") {
+			err = b.parseReferences(p, schema, tableName, constraintName, []string{name})
+		} else if p.accept("generated") {
+			if !p.accept("always") {
+				err = p.expect("by", "default")
+			}
+			if err == nil && p.accept("as", "identity") {
+				col.IsAutoIncrement = true
+				col.Nullable = false
+				if p.peek().is("(") {
+					err = p.skipParens()
+				}
+			} else if err == nil {
+				// generated (computed) column: GENERATED ALWAYS AS (expr) STORED
+				err = p.expect("as")
+				if err == nil {
+					err = p.skipParens()
+				}
+				p.accept("stored")
+			}
+		} else if p.peek().is("(") {
+			// CHECK (...) and other parenthesized clauses
+			err = p.skipParens()
+		} else {
+			p.next()
+		}
+		if err != nil {
+			return err
+		}
+		// a constraint name applies to the next constraint only
+		constraintName = ""
+	}
+
+	table.Columns = append(table.Columns, col)
+	if isPrimaryKey {
+		err = b.addConstraint(table, primaryKeyName, metadata.PrimaryKeyConstraint, []string{name})
+		if err != nil {
+			return err
+		}
+	}
+	if isUnique {
+		return b.addConstraint(table, uniqueName, metadata.UniqueConstraint, []string{name})
+	}
+	return nil
+}
+
+// expression returns the source text of a DEFAULT expression, which ends
+// where the next column constraint starts
+func (p *parser) expression() string {
+	first := p.next()
+	if first == nil {
+		return ""
+	}
+	last := first
+	depth := 0
+	if first.is("(") {
+		depth++
+	}
+	for !p.done() {
+		tok := p.peek()
+		if depth == 0 && tok.Kind == TokenIdent && columnConstraintKeywords[tok.Text] {
+			break
+		}
+		if tok.is("(") {
+			depth++
+		} else if tok.is(")") {
+			depth--
+		}
+		last = p.next()
+	}
+	return p.src[first.Start:last.End]
+}
+
+func (b *schemaBuilder) parseReferences(p *parser, schema string, tableName string, name string, columns []string) error {
+	refSchema, refTable, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	var refCols []string
+	if p.peek().is("(") {
+		refCols, err = p.identList()
+		if err != nil {
+			return err
+		}
+	}
+	fk := ddlFkInfo{
+		Name:             name,
+		Schema:           schema,
+		Table:            tableName,
+		Columns:          columns,
+		ReferencedSchema: refSchema,
+		ReferencedTable:  refTable,
+		ReferencedCols:   refCols,
+		OnDelete:         "NO ACTION",
+		OnUpdate:         "NO ACTION",
+	}
+	if fk.Name == "" {
+		fk.Name = metadata.DefaultConstraintName(tableName, metadata.ForeignKeyConstraint, columns)
+	}
+
+	// MATCH and deferrability don't matter here
+	for {
+		if p.accept("on", "delete") || p.accept("on", "update") {
+			action := &fk.OnDelete
+			if p.tokens[p.pos-1].is("update") {
+				action = &fk.OnUpdate
+			}
+			start := p.pos
+			if !p.accept("cascade") && !p.accept("restrict") && !p.accept("no", "action") &&
+				!p.accept("set", "null") && !p.accept("set", "default") {
+				return p.errorf("expected referential action")
+			}
+			words := make([]string, 0)
+			for _, tok := range p.tokens[start:p.pos] {
+				words = append(words, strings.ToUpper(tok.Text))
+			}
+			*action = strings.Join(words, " ")
+			// SET NULL (col, ...) only clears some of the columns
+			if p.peek().is("(") {
+				err = p.skipParens()
+				if err != nil {
+					return err
+				}
+			}
+		} else if p.accept("match") {
+			p.next()
+		} else if p.accept("initially") {
+			p.next()
+		} else if !p.accept("deferrable") && !p.accept("not", "deferrable") {
+			b.fks = append(b.fks, fk)
+			return nil
+		}
+	}
+}
+
+func (b *schemaBuilder) parseTableConstraint(p *parser, schema string, tableName string) error {
+	var name string
+	if p.accept("constraint") {
+		var err error
+		name, err = p.identifier()
+		if err != nil {
+			return err
+		}
+	}
+	if p.accept("primary", "key") {
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		return b.addConstraint(b.table(schema, tableName), name, metadata.PrimaryKeyConstraint, columns)
+	}
+	if p.accept("unique") {
+		if p.accept("nulls") {
+			p.accept("not")
+			err := p.expect("distinct")
+			if err != nil {
+				return err
+			}
+		}
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		return b.addConstraint(b.table(schema, tableName), name, metadata.UniqueConstraint, columns)
+	}
+	if p.accept("foreign", "key") {
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		err = p.expect("references")
+		if err != nil {
+			return err
+		}
+		return b.parseReferences(p, schema, tableName, name, columns)
+	}
+
+	// CHECK and EXCLUDE constraints are not part of the metadata
+	return nil
+}
+
+func (b *schemaBuilder) parseAlterTable(p *parser) error {
+	p.accept("if", "exists")
+	p.accept("only")
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	table := b.table(schema, name)
+	if table == nil {
+		// not created by this script (or filtered out), nothing to alter
+		return nil
+	}
+
+	actions := splitTopLevel(p.rest())
+	for i := range actions {
+		ap := &parser{src: b.src, tokens: actions[i]}
+		err = b.parseAlterAction(ap, schema, name)
+		if err != nil {
+			return fmt.Errorf("table %s.%s: %w", schema, name, err)
+		}
+	}
+	return nil
+}
+
+func (b *schemaBuilder) parseAlterAction(p *parser, schema string, tableName string) error {
+	if p.accept("add") {
+		if isTableConstraint(p) {
+			return b.parseTableConstraint(p, schema, tableName)
+		}
+		p.accept("column")
+		p.accept("if", "not", "exists")
+		return b.parseColumnDef(p, schema, tableName)
+	}
+
+	if p.accept("alter") {
+		p.accept("column")
+		name, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		col := b.table(schema, tableName).SearchColumnByName(name)
+		if col == nil {
+			return fmt.Errorf("column %s not found", name)
+		}
+		if p.accept("set", "not", "null") {
+			col.Nullable = false
+		} else if p.accept("drop", "not", "null") {
+			col.Nullable = true
+		} else if p.accept("set", "default") {
+			defaultValue := p.expression()
+			col.DefaultValue = &defaultValue
+			if strings.HasPrefix(strings.ToLower(defaultValue), "nextval(") {
+				col.IsAutoIncrement = true
+			}
+		} else if p.accept("drop", "default") {
+			col.DefaultValue = nil
+		} else if p.accept("add", "generated") {
+			col.IsAutoIncrement = true
+			col.Nullable = false
+		} else if p.accept("set", "data", "type") || p.accept("type") {
+			typeName, modifiers, dims, err := p.dataType()
+			if err != nil {
+				return err
+			}
+			setColumnType(col, typeName, modifiers, dims)
+		}
+		return nil
+	}
+
+	if p.accept("drop") {
+		if p.accept("constraint") {
+			p.accept("if", "exists")
+			name, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			b.dropConstraint(b.table(schema, tableName), name)
+			return nil
+		}
+		p.accept("column")
+		p.accept("if", "exists")
+		name, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		table := b.table(schema, tableName)
+		for i := range table.Columns {
+			if table.Columns[i].Name == name {
+				table.Columns = append(table.Columns[:i], table.Columns[i+1:]...)
+				break
+			}
+		}
+		for i := range table.Columns {
+			table.Columns[i].Ordinal = i + 1
+		}
+		return nil
+	}
+
+	// OWNER TO, RENAME, SET ... and friends don't change the metadata
+	return nil
+}
+
+// parseCreateIndex reads an index on plain columns. Expression and partial
+// indexes are left out, as lookups by column values can't count on them.
+func (b *schemaBuilder) parseCreateIndex(p *parser, unique bool) error {
+	p.accept("concurrently")
+	p.accept("if", "not", "exists")
+	var name string
+	var err error
+	if !p.peek().is("on") {
+		name, err = p.identifier()
+		if err != nil {
+			return err
+		}
+	}
+	err = p.expect("on")
+	if err != nil {
+		return err
+	}
+	p.accept("only")
+	schema, tableName, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	table := b.table(schema, tableName)
+	if table == nil {
+		// not created by this script (or filtered out)
+		return nil
+	}
+	if p.accept("using") {
+		p.next()
+	}
+	if !p.peek().is("(") {
+		return p.errorf("expected index column list")
+	}
+
+	start := p.pos + 1
+	err = p.skipParens()
+	if err != nil {
+		return err
+	}
+	elements := splitTopLevel(p.tokens[start : p.pos-1])
+	columns := make([]string, 0)
+	for i := range elements {
+		// a column or an expression, followed by COLLATE, opclass, ASC...
+		ep := &parser{src: b.src, tokens: elements[i]}
+		if ep.peek().is("(") || (len(elements[i]) > 1 && elements[i][1].is("(")) {
+			return nil
+		}
+		column, err := ep.identifier()
+		if err != nil {
+			return err
+		}
+		if table.SearchColumnByName(column) == nil {
+			return fmt.Errorf("index column %s not found in table %s.%s", column, schema, tableName)
+		}
+		columns = append(columns, column)
+	}
+
+	// INCLUDE, WITH and TABLESPACE don't matter, WHERE makes a partial index
+	for !p.done() {
+		if p.peek().is("(") {
+			err = p.skipParens()
+			if err != nil {
+				return err
+			}
+		} else if p.accept("where") {
+			return nil
+		} else {
+			p.next()
+		}
+	}
+
+	if name == "" {
+		name = tableName + "_" + strings.Join(columns, "_") + "_idx"
+	}
+	table.Indexes = append(table.Indexes, metadata.Index{
+		Name:     name,
+		Columns:  columns,
+		IsUnique: unique,
+	})
+	return nil
+}
+
+// parseDropIndex removes the dropped indexes from the tables of their schema
+func (b *schemaBuilder) parseDropIndex(p *parser) error {
+	p.accept("concurrently")
+	p.accept("if", "exists")
+	for {
+		schema, name, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		for i := range b.tables {
+			if b.tables[i].Schema == schema && b.dropIndex(&b.tables[i], name) {
+				break
+			}
+		}
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+// parseComment reads COMMENT ON TABLE and COMMENT ON COLUMN, IS NULL
+// removing the comment. Comments on other objects are not part of the
+// metadata.
+func (b *schemaBuilder) parseComment(p *parser) error {
+	isColumn := p.accept("column")
+	if !isColumn && !p.accept("table") {
+		return nil
+	}
+	names := make([]string, 0)
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		if !p.accept(".") {
+			break
+		}
+	}
+	err := p.expect("is")
+	if err != nil {
+		return err
+	}
+	comment := ""
+	if !p.accept("null") {
+		tok := p.next()
+		if tok == nil || tok.Kind != TokenString {
+			return p.errorf("expected comment string")
+		}
+		comment = tok.Text
+	}
+
+	columnName := ""
+	if isColumn {
+		columnName = names[len(names)-1]
+		names = names[:len(names)-1]
+	}
+	if len(names) == 0 || len(names) > 2 {
+		return p.errorf("invalid comment target")
+	}
+	schema, tableName := "public", names[len(names)-1]
+	if len(names) == 2 {
+		schema = names[0]
+	}
+	table := b.table(schema, tableName)
+	if table == nil {
+		// not created by this script (or filtered out)
+		return nil
+	}
+
+	if !isColumn {
+		table.Comment = comment
+		return nil
+	}
+	col := table.SearchColumnByName(columnName)
+	if col == nil {
+		return fmt.Errorf("column %s not found in table %s.%s", columnName, schema, tableName)
+	}
+	col.Comment = comment
+	return nil
+}
+
+// parseCreateType reads enum types. Composite, range and base types are not
+// part of the metadata.
+func (b *schemaBuilder) parseCreateType(p *parser) error {
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.accept("as", "enum") {
+		return nil
+	}
+	if b.enum(schema, name) != nil {
+		return fmt.Errorf("type %s.%s defined twice", schema, name)
+	}
+
+	err = p.expect("(")
+	if err != nil {
+		return err
+	}
+	enum := metadata.Enum{Schema: schema, Name: name, Labels: make([]string, 0)}
+	for !p.accept(")") {
+		tok := p.next()
+		if tok == nil || tok.Kind != TokenString {
+			return fmt.Errorf("type %s.%s: %w", schema, name, p.errorf("expected enum label"))
+		}
+		enum.Labels = append(enum.Labels, tok.Text)
+		if !p.peek().is(")") {
+			err = p.expect(",")
+			if err != nil {
+				return fmt.Errorf("type %s.%s: %w", schema, name, err)
+			}
+		}
+	}
+	b.enums = append(b.enums, enum)
+	return nil
+}
+
+// parseAlterType applies ADD VALUE and RENAME VALUE to enum types
+func (b *schemaBuilder) parseAlterType(p *parser) error {
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	enum := b.enum(schema, name)
+	if enum == nil {
+		return nil
+	}
+
+	if p.accept("add", "value") {
+		ifNotExists := p.accept("if", "not", "exists")
+		tok := p.next()
+		if tok == nil || tok.Kind != TokenString {
+			return p.errorf("expected enum label")
+		}
+		if metadata.ContainsString(enum.Labels, tok.Text) {
+			if ifNotExists {
+				return nil
+			}
+			return fmt.Errorf("enum label %s already exists in type %s.%s", tok.Text, schema, name)
+		}
+
+		// new labels go last, unless placed before or after another one
+		position := len(enum.Labels)
+		before := p.accept("before")
+		if before || p.accept("after") {
+			neighbour := p.next()
+			if neighbour == nil || neighbour.Kind != TokenString {
+				return p.errorf("expected enum label")
+			}
+			position = -1
+			for i := range enum.Labels {
+				if enum.Labels[i] == neighbour.Text {
+					position = i
+				}
+			}
+			if position < 0 {
+				return fmt.Errorf("enum label %s not found in type %s.%s", neighbour.Text, schema, name)
+			}
+			if !before {
+				position++
+			}
+		}
+		enum.Labels = append(enum.Labels[:position], append([]string{tok.Text}, enum.Labels[position:]...)...)
+		return nil
+	}
+
+	if p.accept("rename", "value") {
+		from := p.next()
+		if from == nil || from.Kind != TokenString || !p.accept("to") {
+			return p.errorf("expected enum label")
+		}
+		to := p.next()
+		if to == nil || to.Kind != TokenString {
+			return p.errorf("expected enum label")
+		}
+		for i := range enum.Labels {
+			if enum.Labels[i] == from.Text {
+				enum.Labels[i] = to.Text
+				return nil
+			}
+		}
+		return fmt.Errorf("enum label %s not found in type %s.%s", from.Text, schema, name)
+	}
+
+	return nil
+}
+
+// resolveForeignKeys adds the collected references to the constraints of
+// their tables, and to the columns of single column ones. It runs after the
+// whole script is read, so tables can reference tables that are created
+// later on.
+func (b *schemaBuilder) resolveForeignKeys() error {
+	for i := range b.fks {
+		fk := b.fks[i]
+		table := b.table(fk.Schema, fk.Table)
+		refTable := b.table(fk.ReferencedSchema, fk.ReferencedTable)
+
+		refCols := fk.ReferencedCols
+		if refCols == nil {
+			// a reference without a column list points to the primary key
+			if refTable == nil {
+				return fmt.Errorf("table %s.%s references unknown table %s.%s",
+					fk.Schema, fk.Table, fk.ReferencedSchema, fk.ReferencedTable)
+			}
+			for _, pk := range refTable.SearchConstraints(metadata.PrimaryKeyConstraint) {
+				refCols = pk.Columns
+			}
+		}
+		if len(refCols) != len(fk.Columns) {
+			return fmt.Errorf("foreign key of %s.%s has %d columns but references %d",
+				fk.Schema, fk.Table, len(fk.Columns), len(refCols))
+		}
+
+		for j := range fk.Columns {
+			if table.SearchColumnByName(fk.Columns[j]) == nil {
+				return fmt.Errorf("foreign key column %s not found in table %s.%s", fk.Columns[j], fk.Schema, fk.Table)
+			}
+		}
+		table.Constraints = append(table.Constraints, metadata.Constraint{
+			Name:       fk.Name,
+			Type:       metadata.ForeignKeyConstraint,
+			Columns:    fk.Columns,
+			RefSchema:  fk.ReferencedSchema,
+			RefTable:   fk.ReferencedTable,
+			RefColumns: refCols,
+			OnDelete:   fk.OnDelete,
+			OnUpdate:   fk.OnUpdate,
+		})
+		if len(fk.Columns) == 1 {
+			table.SearchColumnByName(fk.Columns[0]).FkTarget = &metadata.ForeignKeyTarget{
+				Schema: fk.ReferencedSchema,
+				Table:  fk.ReferencedTable,
+				Column: refCols[0],
+			}
+		}
+	}
+	return nil
+}
+
+// ParseDDL builds the metadata of the tables created by a PostgreSQL DDL
+// script. When schemas is not empty, only tables of those schemas are kept.
+// Views are skipped, unlike when reading a live database.
+func ParseDDL(src string, database string, schemas []string) (*metadata.Metadata, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := schemaBuilder{
+		src:    src,
+		tables: make([]metadata.Table, 0),
+		index:  make(map[string]int),
+		fks:    make([]ddlFkInfo, 0),
+		enums:  make([]metadata.Enum, 0),
+	}
+
+	statements := splitStatements(tokens)
+	for i := range statements {
+		p := &parser{src: src, tokens: statements[i]}
+		err = builder.parseStatement(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = builder.resolveForeignKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]metadata.Table, 0)
+	for i := range builder.tables {
+		if len(schemas) == 0 || metadata.ContainsString(schemas, builder.tables[i].Schema) {
+			tables = append(tables, builder.tables[i])
+		}
+	}
+
+	meta := &metadata.Metadata{
+		Database: database,
+		Tables:   tables,
+		Enums:    make([]metadata.Enum, 0),
+	}
+	// keep the enums of the selected schemas and those used by their tables
+	for i := range builder.enums {
+		enum := builder.enums[i]
+		used := false
+		for j := range tables {
+			for k := range tables[j].Columns {
+				col := &tables[j].Columns[k]
+				if (col.Datatype == "USER-DEFINED" || col.IsArray()) && col.UdtSchema == enum.Schema && col.ElementUdtName() == enum.Name {
+					used = true
+				}
+			}
+		}
+		if used || len(schemas) == 0 || metadata.ContainsString(schemas, enum.Schema) {
+			meta.Enums = append(meta.Enums, enum)
+		}
+	}
+
+	return meta, nil
+}
+
+func ReadDDLMetadata(ddlFile string, config config.Config) (*metadata.Metadata, error) {
+	fmt.Printf("DDL File: %s\n", ddlFile)
+
+	data, err := os.ReadFile(ddlFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ddl file: %w", err)
+	}
+
+	meta, err := ParseDDL(string(data), config.ConnInfo.Database, config.ConnInfo.Schemas)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ddl file: %w", err)
+	}
+	return meta, nil
+}
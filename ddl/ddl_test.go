@@ -0,0 +1,334 @@
+package ddl
+
+import (
+	"dto-gen/metadata"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *metadata.Metadata {
+	t.Helper()
+	meta, err := ParseDDL(src, "shop", nil)
+	if err != nil {
+		t.Fatalf("ParseDDL() error: %v", err)
+	}
+	return meta
+}
+
+func mustTable(t *testing.T, meta *metadata.Metadata, schema string, name string) *metadata.Table {
+	t.Helper()
+	table := meta.SearchTable(schema, name)
+	if table == nil {
+		t.Fatalf("table %s.%s not found", schema, name)
+	}
+	return table
+}
+
+func mustColumn(t *testing.T, table *metadata.Table, name string) *metadata.Column {
+	t.Helper()
+	col := table.SearchColumnByName(name)
+	if col == nil {
+		t.Fatalf("column %s not found in table %s", name, table.Name)
+	}
+	return col
+}
+
+func TestIdentityColumns(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE item (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    seq int GENERATED BY DEFAULT AS IDENTITY (START WITH 10 INCREMENT BY 2),
+    code serial,
+    total numeric GENERATED ALWAYS AS (1) STORED
+);`)
+	table := mustTable(t, meta, "public", "item")
+
+	tests := []struct {
+		column        string
+		datatype      string
+		udtName       string
+		autoIncrement bool
+		nullable      bool
+	}{
+		{"id", "bigint", "int8", true, false},
+		{"seq", "integer", "int4", true, false},
+		{"code", "integer", "int4", true, false},
+		{"total", "numeric", "numeric", false, true},
+	}
+	for _, tt := range tests {
+		col := mustColumn(t, table, tt.column)
+		if col.Datatype != tt.datatype || col.UdtName != tt.udtName {
+			t.Errorf("%s: type %s (%s), want %s (%s)", tt.column, col.Datatype, col.UdtName, tt.datatype, tt.udtName)
+		}
+		if col.IsAutoIncrement != tt.autoIncrement {
+			t.Errorf("%s: auto increment %t, want %t", tt.column, col.IsAutoIncrement, tt.autoIncrement)
+		}
+		if col.Nullable != tt.nullable {
+			t.Errorf("%s: nullable %t, want %t", tt.column, col.Nullable, tt.nullable)
+		}
+	}
+	if !mustColumn(t, table, "id").IsPrimaryKey {
+		t.Errorf("id is not the primary key")
+	}
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE "Sales"."Order Items" (
+    "Id" int PRIMARY KEY,
+    Name TEXT,
+    "select" text,
+    "say ""hi""" text
+);`)
+	table := mustTable(t, meta, "Sales", "Order Items")
+
+	names := make([]string, 0)
+	for i := range table.Columns {
+		names = append(names, table.Columns[i].Name)
+	}
+	want := []string{"Id", "name", "select", `say "hi"`}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("columns %q, want %q", names, want)
+	}
+	if pks := table.SearchConstraints(metadata.PrimaryKeyConstraint); len(pks) != 1 || pks[0].Columns[0] != "Id" {
+		t.Errorf("primary key constraints %v, want one on Id", pks)
+	}
+}
+
+func TestColumnTypesAndDefaults(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE t (
+    score int CHECK (score > 0 AND score < 10),
+    tags text DEFAULT 'a,b',
+    at timestamp(3) with time zone NOT NULL,
+    amounts numeric(10,2)[],
+    grid integer[][],
+    local timestamp(6) without time zone,
+    CONSTRAINT ordered CHECK (score < 100),
+    CHECK (tags <> ''),
+    after_checks boolean
+);`)
+	table := mustTable(t, meta, "public", "t")
+	if len(table.Columns) != 7 {
+		t.Fatalf("%d columns, want 7", len(table.Columns))
+	}
+
+	tests := []struct {
+		column      string
+		datatype    string
+		udtName     string
+		elementType string
+		dims        int
+	}{
+		{"score", "integer", "int4", "", 0},
+		{"tags", "text", "text", "", 0},
+		{"at", "timestamp with time zone", "timestamptz", "", 0},
+		{"amounts", "ARRAY", "_numeric", "numeric", 1},
+		{"grid", "ARRAY", "_int4", "integer", 2},
+		{"local", "timestamp without time zone", "timestamp", "", 0},
+		{"after_checks", "boolean", "bool", "", 0},
+	}
+	for _, tt := range tests {
+		col := mustColumn(t, table, tt.column)
+		if col.Datatype != tt.datatype || col.UdtName != tt.udtName || col.ElementType != tt.elementType || col.ArrayDims != tt.dims {
+			t.Errorf("%s: type %s (%s, %s[%d]), want %s (%s, %s[%d])", tt.column,
+				col.Datatype, col.UdtName, col.ElementType, col.ArrayDims,
+				tt.datatype, tt.udtName, tt.elementType, tt.dims)
+		}
+	}
+
+	tags := mustColumn(t, table, "tags")
+	if tags.DefaultValue == nil || *tags.DefaultValue != "'a,b'" {
+		t.Errorf("tags default %v, want 'a,b'", tags.DefaultValue)
+	}
+	if mustColumn(t, table, "at").Nullable {
+		t.Errorf("at is nullable")
+	}
+}
+
+func TestFormattedTypes(t *testing.T) {
+	meta := parse(t, `
+CREATE TYPE mood AS ENUM ('sad', 'happy');
+CREATE TABLE t (
+    isbn char(13),
+    flag char,
+    name varchar(40),
+    note varchar,
+    total numeric(10, 2),
+    whole decimal(10),
+    amount numeric,
+    mask bit,
+    bits varbit(8),
+    at time(3),
+    seen timestamptz(0),
+    codes character varying(5)[],
+    feeling mood
+);
+ALTER TABLE t ALTER COLUMN note TYPE varchar(80);`)
+	table := mustTable(t, meta, "public", "t")
+
+	tests := []struct {
+		column        string
+		formattedType string
+	}{
+		{"isbn", "character(13)"},
+		{"flag", "character(1)"},
+		{"name", "character varying(40)"},
+		{"note", "character varying(80)"},
+		{"total", "numeric(10,2)"},
+		{"whole", "numeric(10,0)"},
+		{"amount", "numeric"},
+		{"mask", "bit(1)"},
+		{"bits", "bit varying(8)"},
+		{"at", "time(3) without time zone"},
+		{"seen", "timestamp(0) with time zone"},
+		{"codes", "character varying(5)"},
+		{"feeling", ""},
+	}
+	for _, tt := range tests {
+		if got := mustColumn(t, table, tt.column).FormattedType; got != tt.formattedType {
+			t.Errorf("%s: formatted type %q, want %q", tt.column, got, tt.formattedType)
+		}
+	}
+}
+
+func TestAlterTableAddConstraint(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE invoice (region char(2), num int, UNIQUE (region, num));
+CREATE TABLE customer (id int);
+CREATE TABLE line (
+    region char(2),
+    num int,
+    pos int,
+    customer_id int
+);
+ALTER TABLE ONLY public.line ADD CONSTRAINT line_pk PRIMARY KEY (region, num, pos);
+ALTER TABLE line ADD CONSTRAINT line_invoice_fk FOREIGN KEY (region, num) REFERENCES invoice (region, num) ON DELETE CASCADE ON UPDATE SET NULL;
+ALTER TABLE customer ADD PRIMARY KEY (id);
+ALTER TABLE line ADD FOREIGN KEY (customer_id) REFERENCES customer ON UPDATE NO ACTION ON DELETE SET DEFAULT;
+`)
+	line := mustTable(t, meta, "public", "line")
+
+	pks := line.SearchConstraints(metadata.PrimaryKeyConstraint)
+	if len(pks) != 1 || pks[0].Name != "line_pk" || strings.Join(pks[0].Columns, ",") != "region,num,pos" {
+		t.Fatalf("primary key constraints %+v, want line_pk (region, num, pos)", pks)
+	}
+	for _, name := range []string{"region", "num", "pos"} {
+		col := mustColumn(t, line, name)
+		if !col.IsPrimaryKey || col.Nullable {
+			t.Errorf("%s: primary key %t, nullable %t", name, col.IsPrimaryKey, col.Nullable)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		columns    string
+		refTable   string
+		refColumns string
+		onDelete   string
+		onUpdate   string
+	}{
+		{"line_invoice_fk", "region,num", "invoice", "region,num", "CASCADE", "SET NULL"},
+		{"line_customer_id_fkey", "customer_id", "customer", "id", "SET DEFAULT", "NO ACTION"},
+	}
+	fks := line.SearchConstraints(metadata.ForeignKeyConstraint)
+	if len(fks) != len(tests) {
+		t.Fatalf("%d foreign keys, want %d", len(fks), len(tests))
+	}
+	for i, tt := range tests {
+		fk := fks[i]
+		if fk.Name != tt.name || strings.Join(fk.Columns, ",") != tt.columns || fk.RefSchema != "public" ||
+			fk.RefTable != tt.refTable || strings.Join(fk.RefColumns, ",") != tt.refColumns ||
+			fk.OnDelete != tt.onDelete || fk.OnUpdate != tt.onUpdate {
+			t.Errorf("foreign key %+v, want %+v", *fk, tt)
+		}
+	}
+
+	// only foreign keys of a single column have a target
+	if target := mustColumn(t, line, "customer_id").FkTarget; target == nil || target.Table != "customer" || target.Column != "id" {
+		t.Errorf("customer_id targets %+v, want customer.id", target)
+	}
+	if target := mustColumn(t, line, "region").FkTarget; target != nil {
+		t.Errorf("region targets %+v, want none", target)
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE account (id int PRIMARY KEY, email text, active boolean, name text);
+CREATE UNIQUE INDEX account_active_email ON account (email) WHERE active;
+CREATE INDEX account_lower_name ON account (lower(name));
+CREATE UNIQUE INDEX IF NOT EXISTS account_email ON public.account USING btree (email DESC NULLS LAST) INCLUDE (name);
+CREATE INDEX account_name ON account (name, email);
+CREATE INDEX account_dropped ON account (active);
+DROP INDEX IF EXISTS public.account_dropped;
+`)
+	account := mustTable(t, meta, "public", "account")
+
+	got := make([]string, 0)
+	for _, index := range account.Indexes {
+		got = append(got, index.Name+"("+strings.Join(index.Columns, ",")+")")
+		if index.Name == "account_email" && !index.IsUnique {
+			t.Errorf("account_email is not unique")
+		}
+	}
+	// partial and expression indexes are left out
+	want := []string{"account_pkey(id)", "account_email(email)", "account_name(name,email)"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("indexes %v, want %v", got, want)
+	}
+}
+
+func TestDollarQuotedBodies(t *testing.T) {
+	meta := parse(t, `
+CREATE FUNCTION f() RETURNS void AS $$ CREATE TABLE fake (a int); $$ LANGUAGE sql;
+CREATE FUNCTION g() RETURNS trigger AS $body$
+BEGIN
+    EXECUTE 'CREATE TABLE fake2 (a int)'; -- $$ is not the end
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+CREATE TABLE real (a int);
+`)
+	if len(meta.Tables) != 1 || meta.Tables[0].Name != "real" {
+		names := make([]string, 0)
+		for i := range meta.Tables {
+			names = append(names, meta.Tables[i].Name)
+		}
+		t.Errorf("tables %v, want [real]", names)
+	}
+}
+
+func TestComments(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE billing.invoice (id int, total numeric);
+COMMENT ON TABLE billing.invoice IS 'Issued invoices, it''s
+two lines';
+COMMENT ON COLUMN billing.invoice.total IS E'Tax \'included\'';
+COMMENT ON COLUMN billing.invoice.id IS 'dropped';
+COMMENT ON COLUMN billing.invoice.id IS NULL;
+COMMENT ON INDEX invoice_idx IS 'ignored';
+`)
+	invoice := mustTable(t, meta, "billing", "invoice")
+	if invoice.Comment != "Issued invoices, it's\ntwo lines" {
+		t.Errorf("table comment %q", invoice.Comment)
+	}
+	if comment := mustColumn(t, invoice, "total").Comment; comment != "Tax 'included'" {
+		t.Errorf("total comment %q", comment)
+	}
+	if comment := mustColumn(t, invoice, "id").Comment; comment != "" {
+		t.Errorf("id comment %q, want none", comment)
+	}
+}
+
+func TestViewsAreSkipped(t *testing.T) {
+	meta := parse(t, `
+CREATE TABLE t (a int);
+CREATE VIEW v AS SELECT a FROM t;
+CREATE OR REPLACE VIEW public.w AS SELECT 1 AS b;
+CREATE MATERIALIZED VIEW IF NOT EXISTS m AS SELECT a FROM t;
+`)
+	if len(meta.Tables) != 1 || meta.Tables[0].Name != "t" {
+		t.Errorf("%d tables, want t alone", len(meta.Tables))
+	}
+}
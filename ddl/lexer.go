@@ -0,0 +1,214 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type TokenKind int
+
+const (
+	TokenIdent TokenKind = iota
+	TokenQuotedIdent
+	TokenString
+	TokenNumber
+	TokenSymbol
+)
+
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Start int
+	End   int
+}
+
+// is reports whether the token is the given (lowercase) keyword or symbol.
+// Quoted identifiers never match, since "null" is a name and not a keyword.
+func (t *Token) is(text string) bool {
+	if t == nil {
+		return false
+	}
+	return (t.Kind == TokenIdent || t.Kind == TokenSymbol) && t.Text == text
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// characters written with a backslash in E'...' strings, other escaped
+// characters standing for themselves
+var backslashEscapes = map[rune]rune{
+	'b': '\b',
+	'f': '\f',
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+}
+
+// tokenize splits a SQL script into tokens, dropping whitespace and comments.
+// Unquoted identifiers are folded to lower case, as PostgreSQL does.
+func tokenize(src string) ([]Token, error) {
+	runes := []rune(src)
+	// token offsets are byte offsets into src, so keep a rune -> byte index
+	offsets := make([]int, len(runes)+1)
+	byteIdx := 0
+	for i, r := range runes {
+		offsets[i] = byteIdx
+		byteIdx += len(string(r))
+	}
+	offsets[len(runes)] = byteIdx
+
+	tokens := make([]Token, 0)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", offsets[start])
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+
+		case r == '\'' || ((r == 'e' || r == 'E') && i+1 < len(runes) && runes[i+1] == '\''):
+			// E'...' strings take C-style backslash escapes
+			escapes := r != '\''
+			if escapes {
+				i++
+			}
+			i++
+			var text strings.Builder
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string at offset %d", offsets[start])
+				}
+				if escapes && runes[i] == '\\' && i+1 < len(runes) {
+					if escaped, exists := backslashEscapes[runes[i+1]]; exists {
+						text.WriteRune(escaped)
+					} else {
+						text.WriteRune(runes[i+1])
+					}
+					i += 2
+					continue
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						text.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, Token{Kind: TokenString, Text: text.String(), Start: offsets[start], End: offsets[i]})
+
+		case r == '"':
+			i++
+			var text strings.Builder
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated quoted identifier at offset %d", offsets[start])
+				}
+				if runes[i] == '"' {
+					if i+1 < len(runes) && runes[i+1] == '"' {
+						text.WriteRune('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, Token{Kind: TokenQuotedIdent, Text: text.String(), Start: offsets[start], End: offsets[i]})
+
+		case r == '$' && i+1 < len(runes) && (runes[i+1] == '$' || isIdentStart(runes[i+1])):
+			// dollar quoted string, as used by function bodies: $tag$ ... $tag$
+			j := i + 1
+			for j < len(runes) && runes[j] != '$' && isIdentPart(runes[j]) {
+				j++
+			}
+			if j >= len(runes) || runes[j] != '$' {
+				return nil, fmt.Errorf("invalid dollar quote at offset %d", offsets[start])
+			}
+			tag := string(runes[i : j+1])
+			end := strings.Index(string(runes[j+1:]), tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar quoted string at offset %d", offsets[start])
+			}
+			body := string(runes[j+1:])[:end]
+			i = j + 1 + len([]rune(body)) + len([]rune(tag))
+			tokens = append(tokens, Token{Kind: TokenString, Text: body, Start: offsets[start], End: offsets[i]})
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, Token{Kind: TokenNumber, Text: string(runes[start:i]), Start: offsets[start], End: offsets[i]})
+
+		case isIdentStart(r):
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			text := strings.ToLower(string(runes[start:i]))
+			tokens = append(tokens, Token{Kind: TokenIdent, Text: text, Start: offsets[start], End: offsets[i]})
+
+		default:
+			i++
+			if r == ':' && i < len(runes) && runes[i] == ':' {
+				i++
+			}
+			tokens = append(tokens, Token{Kind: TokenSymbol, Text: string(runes[start:i]), Start: offsets[start], End: offsets[i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+// splitStatements groups tokens into statements separated by ';'
+func splitStatements(tokens []Token) [][]Token {
+	statements := make([][]Token, 0)
+	current := make([]Token, 0)
+	for i := range tokens {
+		if tokens[i].is(";") {
+			if len(current) > 0 {
+				statements = append(statements, current)
+			}
+			current = make([]Token, 0)
+			continue
+		}
+		current = append(current, tokens[i])
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements
+}
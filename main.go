@@ -2,10 +2,15 @@ package main
 
 import (
 	config2 "dto-gen/config"
+	"dto-gen/ddl"
 	metadata2 "dto-gen/metadata"
+	"dto-gen/metadiff"
 	"dto-gen/metago"
 	"dto-gen/metapy"
+	"dto-gen/migration"
+	"dto-gen/mysql"
 	"dto-gen/pgsql"
+	"dto-gen/sqlite"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -20,23 +25,140 @@ func isValidDirectoryName(name string) bool {
 	return re.MatchString(name)
 }
 
-func readMetadata(config config2.Config) (*metadata2.Metadata, error) {
-	if config.ConnInfo.DBMS == "PostgreSQL" {
+func readMetadata(folder string, config config2.Config) (*metadata2.Metadata, error) {
+	if config.DDLFile != "" {
+		// offline mode, the schema comes from a DDL script next to db.json
+		return ddl.ReadDDLMetadata(filepath.Join(folder, config.DDLFile), config)
+	} else if config.ConnInfo.DBMS == "PostgreSQL" {
 		return pgsql.ReadPostgresMetadata(config)
+	} else if config.ConnInfo.DBMS == "MySQL" || config.ConnInfo.DBMS == "MariaDB" {
+		return mysql.ReadMySQLMetadata(config)
+	} else if config.ConnInfo.DBMS == "SQLite" {
+		return sqlite.ReadSQLiteMetadata(config)
 	}
 
 	// no metadata read
 	return nil, fmt.Errorf("unsupported DMBS: %s", config.ConnInfo.DBMS)
 }
 
+// readConfig reads the db.json of a folder
+func readConfig(folder string) (*config2.Config, error) {
+	data, err := os.ReadFile(filepath.Join(folder, "db.json"))
+	if err != nil {
+		return nil, err
+	}
+	var config config2.Config
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// runDiff prints the changes between two snapshots. It exits with status 2
+// when some of them break the generated API, so it can gate pull requests.
+// The type overrides of the db.json in folder, when given, are taken into
+// account.
+func runDiff(oldFile string, newFile string, folder string) {
+	var config *config2.Config
+	if folder != "" {
+		var err error
+		config, err = readConfig(folder)
+		if err != nil {
+			fmt.Println("Error reading config file: ", err)
+			os.Exit(1)
+		}
+	}
+
+	changes, err := metadiff.DiffFiles(oldFile, newFile, config)
+	if err != nil {
+		fmt.Println("Error comparing snapshots: ", err)
+		os.Exit(1)
+	}
+
+	breaking := 0
+	for i := range changes {
+		fmt.Println(changes[i].String())
+		if changes[i].IsBreaking() {
+			breaking++
+		}
+	}
+	fmt.Printf("%d changes, %d breaking\n", len(changes), breaking)
+
+	if breaking > 0 {
+		os.Exit(2)
+	}
+}
+
+// runMigrate writes the up and down scripts that take a PostgreSQL database
+// from the old snapshot to the new one
+func runMigrate(oldFile string, newFile string, folder string, name string) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		fmt.Println("Invalid migration name. Should be lowercase alphanumeric or underscore only!")
+		os.Exit(1)
+	}
+
+	oldSnapshot, err := metadata2.ReadSnapshot(oldFile)
+	if err != nil {
+		fmt.Println("Error reading snapshot: ", err)
+		os.Exit(1)
+	}
+	newSnapshot, err := metadata2.ReadSnapshot(newFile)
+	if err != nil {
+		fmt.Println("Error reading snapshot: ", err)
+		os.Exit(1)
+	}
+
+	upFile, downFile, err := migration.WriteMigration(oldSnapshot, newSnapshot, folder, name)
+	if err != nil {
+		fmt.Println("Error writing migration: ", err)
+		os.Exit(1)
+	}
+	fmt.Println("Generated " + upFile)
+	fmt.Println("Generated " + downFile)
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: dto-gen <folder-with-db.json>")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "diff" {
+		if len(args) != 3 && len(args) != 4 {
+			fmt.Println("Usage: dto-gen diff <old-snapshot.json> <new-snapshot.json> [folder-with-db.json]")
+			os.Exit(1)
+		}
+		folder := ""
+		if len(args) == 4 {
+			folder = args[3]
+		}
+		runDiff(args[1], args[2], folder)
+		return
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		if len(args) != 4 && len(args) != 5 {
+			fmt.Println("Usage: dto-gen migrate <old-snapshot.json> <new-snapshot.json> <migrations-folder> [name]")
+			os.Exit(1)
+		}
+		name := "schema_change"
+		if len(args) == 5 {
+			name = args[4]
+		}
+		runMigrate(args[1], args[2], args[3], name)
+		return
+	}
+
+	fromSnapshot := false
+	if len(args) > 0 && args[0] == "--from-snapshot" {
+		fromSnapshot = true
+		args = args[1:]
+	}
+	if len(args) < 1 {
+		fmt.Println("Usage: dto-gen [--from-snapshot] <folder-with-db.json>")
+		fmt.Println("       dto-gen diff <old-snapshot.json> <new-snapshot.json> [folder-with-db.json]")
+		fmt.Println("       dto-gen migrate <old-snapshot.json> <new-snapshot.json> <migrations-folder> [name]")
 		os.Exit(1)
 	}
 
-	folder := os.Args[1]
-	parts := strings.Split(os.Args[1], "/")
+	folder := args[0]
+	parts := strings.Split(folder, "/")
 	if !isValidDirectoryName(parts[len(parts)-1]) {
 		fmt.Println("Invalid Directory Name. Should be lowercase alphanumeric only!")
 		os.Exit(1)
@@ -65,13 +187,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// DDL scripts are written in PostgreSQL's dialect
+	if config.DDLFile != "" && config.ConnInfo.DBMS == "" {
+		config.ConnInfo.DBMS = "PostgreSQL"
+	}
+
 	fmt.Println(config)
 
 	// Reading database metadata
-	metadata, err := readMetadata(config)
-	if err != nil {
-		fmt.Println("Error reading metadata: ", err)
-		os.Exit(1)
+	var metadata *metadata2.Metadata
+	snapshotFile := filepath.Join(folder, metadata2.SnapshotFileName)
+	if fromSnapshot {
+		snapshot, err := metadata2.ReadSnapshot(snapshotFile)
+		if err != nil {
+			fmt.Println("Error reading snapshot: ", err)
+			os.Exit(1)
+		}
+		if config.ConnInfo.DBMS == "" {
+			config.ConnInfo.DBMS = snapshot.DBMS
+		} else if config.ConnInfo.DBMS != snapshot.DBMS {
+			fmt.Printf("Snapshot was taken from %s but db config is for %s\n", snapshot.DBMS, config.ConnInfo.DBMS)
+			os.Exit(1)
+		}
+		metadata = &snapshot.Metadata
+	} else {
+		metadata, err = readMetadata(folder, config)
+		if err != nil {
+			fmt.Println("Error reading metadata: ", err)
+			os.Exit(1)
+		}
+
+		if config.Snapshot {
+			err = metadata2.WriteSnapshot(snapshotFile, config.ConnInfo.DBMS, metadata)
+			if err != nil {
+				fmt.Println("Error writing snapshot: ", err)
+				os.Exit(1)
+			}
+		}
 	}
 	// metadata.print()
 
@@ -83,13 +235,13 @@ func main() {
 	}
 
 	if config.Language == "go" {
-		err = metago.WriteGolang(&(config.ConnInfo), folder, metadata, customQueries)
+		err = metago.WriteGolang(&config, folder, metadata, customQueries)
 		if err != nil {
 			fmt.Println("Error writing go source code: ", err)
 			os.Exit(1)
 		}
 	} else if config.Language == "python" {
-		err = metapy.WritePython(&(config.ConnInfo), folder, metadata, customQueries)
+		err = metapy.WritePython(&config, folder, metadata, customQueries)
 		if err != nil {
 			fmt.Println("Error writing python source code: ", err)
 		}
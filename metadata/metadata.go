@@ -6,35 +6,109 @@ import (
 )
 
 type ForeignKeyTarget struct {
-	Schema string
-	Table  string
-	Column string
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Column string `json:"column"`
 }
 
+// Column is a column of a table. Datatype names its type as
+// information_schema.columns.data_type does, and FormattedType spells out
+// the type with its modifiers, as in character varying(40) or numeric(10,2).
+// Array columns format their element type, and user defined types are left
+// to UdtSchema and UdtName.
 type Column struct {
-	Ordinal         int
-	Name            string
-	Datatype        string
-	Nullable        bool
-	DefaultValue    *string
-	IsPrimaryKey    bool
-	IsAutoIncrement bool
-	FkTarget        *ForeignKeyTarget
+	Ordinal         int               `json:"ordinal"`
+	Name            string            `json:"name"`
+	Datatype        string            `json:"datatype"`
+	FormattedType   string            `json:"formatted_type,omitempty"`
+	Nullable        bool              `json:"nullable"`
+	DefaultValue    *string           `json:"default_value,omitempty"`
+	IsPrimaryKey    bool              `json:"is_primary_key"`
+	IsAutoIncrement bool              `json:"is_auto_increment"`
+	FkTarget        *ForeignKeyTarget `json:"fk_target,omitempty"`
+	UdtSchema       string            `json:"udt_schema,omitempty"`
+	UdtName         string            `json:"udt_name,omitempty"`
+	ElementType     string            `json:"element_type,omitempty"`
+	ArrayDims       int               `json:"array_dims,omitempty"`
+	Comment         string            `json:"comment,omitempty"`
+}
+
+// IsArray reports whether the column holds arrays of ElementType
+func (c *Column) IsArray() bool {
+	return c.Datatype == "ARRAY"
+}
+
+// ElementUdtName is the udt name of the column type, or of the elements of
+// an array column. PostgreSQL names array types after their element type
+// with a leading underscore, as in _int4 or _mood.
+func (c *Column) ElementUdtName() string {
+	if c.IsArray() {
+		return strings.TrimPrefix(c.UdtName, "_")
+	}
+	return c.UdtName
+}
+
+// Constraint types, as information_schema names them
+const (
+	PrimaryKeyConstraint = "PRIMARY KEY"
+	UniqueConstraint     = "UNIQUE"
+	ForeignKeyConstraint = "FOREIGN KEY"
+)
+
+// Constraint is a primary key, unique or foreign key constraint, with its
+// columns in key order. A foreign key references RefColumns of RefTable, the
+// nth column referencing the nth referenced column, and OnDelete and
+// OnUpdate hold its referential actions, such as CASCADE or NO ACTION.
+// Columns making up a foreign key on their own also have its FkTarget, while
+// composite foreign keys are only found among the constraints.
+type Constraint struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Columns    []string `json:"columns"`
+	RefSchema  string   `json:"ref_schema,omitempty"`
+	RefTable   string   `json:"ref_table,omitempty"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+	OnDelete   string   `json:"on_delete,omitempty"`
+	OnUpdate   string   `json:"on_update,omitempty"`
+}
+
+// Index is a plain index of a table, with its key columns in order. Partial
+// and expression indexes are left out, as lookups by column values can't
+// count on them.
+type Index struct {
+	Name      string   `json:"name"`
+	Columns   []string `json:"columns"`
+	IsUnique  bool     `json:"is_unique"`
+	IsPrimary bool     `json:"is_primary,omitempty"`
 }
 
 type Table struct {
-	Schema  string
-	Name    string
-	Columns []Column
+	Schema      string       `json:"schema"`
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Constraints []Constraint `json:"constraints,omitempty"`
+	Indexes     []Index      `json:"indexes,omitempty"`
+	Comment     string       `json:"comment,omitempty"`
+}
+
+// Enum is a PostgreSQL enum type, with its labels in sort order
+type Enum struct {
+	Schema string   `json:"schema"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
 }
 
 type Metadata struct {
-	Database string
-	Tables   []Table
+	Database string  `json:"database"`
+	Tables   []Table `json:"tables"`
+	Enums    []Enum  `json:"enums,omitempty"`
 }
 
 func (c *Column) print() {
 	fmt.Printf("    [%d] %s %s", c.Ordinal, c.Name, c.Datatype)
+	if c.IsArray() {
+		fmt.Printf(" OF %s (%d dims)", c.ElementType, c.ArrayDims)
+	}
 	if c.Nullable {
 		fmt.Print(" NULL ")
 	} else {
@@ -61,6 +135,56 @@ func (t *Table) print() {
 	for i := 0; i < len(t.Columns); i++ {
 		t.Columns[i].print()
 	}
+	for i := range t.Constraints {
+		t.Constraints[i].print()
+	}
+	for i := range t.Indexes {
+		t.Indexes[i].print()
+	}
+}
+
+func (x *Index) print() {
+	fmt.Printf("    index %s (%s)", x.Name, strings.Join(x.Columns, ", "))
+	if x.IsPrimary {
+		fmt.Printf(" PRIMARY")
+	} else if x.IsUnique {
+		fmt.Printf(" UNIQUE")
+	}
+	fmt.Printf("\n")
+}
+
+func (c *Constraint) print() {
+	fmt.Printf("    constraint %s %s (%s)", c.Name, c.Type, strings.Join(c.Columns, ", "))
+	if c.Type == ForeignKeyConstraint {
+		fmt.Printf(" REFERENCES %s.%s (%s) ON DELETE %s ON UPDATE %s",
+			c.RefSchema, c.RefTable, strings.Join(c.RefColumns, ", "), c.OnDelete, c.OnUpdate)
+	}
+	fmt.Printf("\n")
+}
+
+// DefaultConstraintName names a constraint declared without a name the way
+// PostgreSQL does, as in customer_pkey, customer_email_key or
+// invoice_customer_id_fkey
+func DefaultConstraintName(table string, constraintType string, columns []string) string {
+	switch constraintType {
+	case PrimaryKeyConstraint:
+		return table + "_pkey"
+	case UniqueConstraint:
+		return table + "_" + strings.Join(columns, "_") + "_key"
+	default:
+		return table + "_" + strings.Join(columns, "_") + "_fkey"
+	}
+}
+
+// SearchConstraints returns the constraints of a table of the given type
+func (t *Table) SearchConstraints(constraintType string) []*Constraint {
+	constraints := make([]*Constraint, 0)
+	for i := range t.Constraints {
+		if t.Constraints[i].Type == constraintType {
+			constraints = append(constraints, &t.Constraints[i])
+		}
+	}
+	return constraints
 }
 
 func (t *Table) SearchColumnByName(name string) *Column {
@@ -77,6 +201,63 @@ func (m *Metadata) print() {
 	for i := 0; i < len(m.Tables); i++ {
 		m.Tables[i].print()
 	}
+	for i := 0; i < len(m.Enums); i++ {
+		fmt.Printf("  enum %s.%s (%s)\n", m.Enums[i].Schema, m.Enums[i].Name, strings.Join(m.Enums[i].Labels, ", "))
+	}
+}
+
+// SearchEnum returns the enum type of the given schema and name, if any
+func (m *Metadata) SearchEnum(schema string, name string) *Enum {
+	for i := range m.Enums {
+		if m.Enums[i].Schema == schema && m.Enums[i].Name == name {
+			return &m.Enums[i]
+		}
+	}
+	return nil
+}
+
+// TableBaseName is the name the files and types generated for a table derive
+// from: its own, prefixed by its schema when a table of another schema goes
+// by the same name
+func (m *Metadata) TableBaseName(table *Table) string {
+	for i := range m.Tables {
+		if m.Tables[i].Name == table.Name && m.Tables[i].Schema != table.Schema {
+			return table.Schema + "_" + table.Name
+		}
+	}
+	return table.Name
+}
+
+// EnumTypeName names the type generated for an enum, keeping clear of the
+// names of the table types
+func (m *Metadata) EnumTypeName(enum *Enum) string {
+	name := ToPascalCase(enum.Name)
+	for i := range m.Tables {
+		if ToPascalCase(m.TableBaseName(&m.Tables[i])) == name {
+			return name + "Enum"
+		}
+	}
+	return name
+}
+
+// ColumnEnum returns the enum type of a column, or of its elements for
+// array columns. It's nil when the column holds no enum.
+func (m *Metadata) ColumnEnum(col *Column) *Enum {
+	if col.Datatype != "USER-DEFINED" && (!col.IsArray() || col.ElementType != "USER-DEFINED") {
+		return nil
+	}
+	return m.SearchEnum(col.UdtSchema, col.ElementUdtName())
+}
+
+// SearchTable returns the table of the given schema and name, if any. An
+// empty schema matches any.
+func (m *Metadata) SearchTable(schema string, name string) *Table {
+	for i := range m.Tables {
+		if m.Tables[i].Name == name && (schema == "" || m.Tables[i].Schema == schema) {
+			return &m.Tables[i]
+		}
+	}
+	return nil
 }
 
 func (m *Metadata) SearchTableByName(name string) *Table {
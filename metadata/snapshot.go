@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SnapshotVersion is bumped whenever the snapshot layout changes in a way
+// older versions of dto-gen can't read. Version 2 made table constraints the
+// source of keys, version 1 snapshots being upgraded as they are read.
+// Indexes and comments came along as optional fields, tables without them
+// reading the same in either version.
+const SnapshotVersion = 2
+
+const SnapshotFileName = "schema.snapshot.json"
+
+// Snapshot is the on-disk form of the metadata a generation ran with. It lets
+// code be regenerated without access to the database, and gives a reviewable
+// record of the schema.
+type Snapshot struct {
+	Version  int      `json:"version"`
+	DBMS     string   `json:"dbms"`
+	Metadata Metadata `json:"metadata"`
+}
+
+func WriteSnapshot(path string, dbms string, metadata *Metadata) error {
+	snapshot := Snapshot{
+		Version:  SnapshotVersion,
+		DBMS:     dbms,
+		Metadata: *metadata,
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %w", err)
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return nil
+}
+
+func ReadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing snapshot %s: %w", path, err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s (this dto-gen reads up to version %d)",
+			snapshot.Version, path, SnapshotVersion)
+	}
+	if snapshot.Version == 1 {
+		upgradeV1(&snapshot)
+	}
+
+	return &snapshot, nil
+}
+
+// upgradeV1 rebuilds what a version 1 snapshot tells of the constraints of
+// its tables, that is their primary keys and single column foreign keys,
+// from the flags of their columns. Unique constraints, indexes and comments
+// weren't recorded, so tables of such snapshots have none.
+func upgradeV1(snapshot *Snapshot) {
+	for i := range snapshot.Metadata.Tables {
+		table := &snapshot.Metadata.Tables[i]
+		if len(table.Constraints) > 0 {
+			continue
+		}
+
+		pks := make([]string, 0)
+		for j := range table.Columns {
+			if table.Columns[j].IsPrimaryKey {
+				pks = append(pks, table.Columns[j].Name)
+			}
+		}
+		if len(pks) > 0 {
+			table.Constraints = append(table.Constraints, Constraint{
+				Name:    DefaultConstraintName(table.Name, PrimaryKeyConstraint, pks),
+				Type:    PrimaryKeyConstraint,
+				Columns: pks,
+			})
+		}
+
+		for j := range table.Columns {
+			target := table.Columns[j].FkTarget
+			if target == nil {
+				continue
+			}
+			columns := []string{table.Columns[j].Name}
+			table.Constraints = append(table.Constraints, Constraint{
+				Name:       DefaultConstraintName(table.Name, ForeignKeyConstraint, columns),
+				Type:       ForeignKeyConstraint,
+				Columns:    columns,
+				RefSchema:  target.Schema,
+				RefTable:   target.Table,
+				RefColumns: []string{target.Column},
+			})
+		}
+	}
+}
@@ -0,0 +1,655 @@
+package metadiff
+
+import (
+	"dto-gen/config"
+	"dto-gen/metadata"
+	"dto-gen/metago"
+	"dto-gen/metapy"
+	"fmt"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	TableAdded           ChangeKind = "table added"
+	TableRemoved         ChangeKind = "table removed"
+	ColumnAdded          ChangeKind = "column added"
+	ColumnRemoved        ChangeKind = "column removed"
+	TypeChanged          ChangeKind = "type changed"
+	NullabilityChanged   ChangeKind = "nullability changed"
+	PrimaryKeyChanged    ChangeKind = "primary key changed"
+	ForeignKeyChanged    ChangeKind = "foreign key changed"
+	AutoIncrementChanged ChangeKind = "auto increment changed"
+	ConstraintAdded      ChangeKind = "constraint added"
+	ConstraintRemoved    ChangeKind = "constraint removed"
+	ConstraintChanged    ChangeKind = "constraint changed"
+	IndexAdded           ChangeKind = "index added"
+	IndexRemoved         ChangeKind = "index removed"
+	IndexChanged         ChangeKind = "index changed"
+	EnumAdded            ChangeKind = "enum added"
+	EnumRemoved          ChangeKind = "enum removed"
+	EnumLabelAdded       ChangeKind = "enum label added"
+	EnumLabelRemoved     ChangeKind = "enum label removed"
+	TypeRenamed          ChangeKind = "type renamed"
+)
+
+// Change is a single difference between two metadata snapshots. BreaksGo and
+// BreaksPython tell whether code written against the DTOs generated from the
+// old snapshot stops compiling (or type checking) against the new ones.
+// Enum changes carry the enum name in Table and the label in Column, index and
+// constraint changes carry the index or constraint name in Column. A table or
+// enum whose generated type is renamed, as when a table of another schema
+// takes the same name, gets a TypeRenamed change from the old to the new name.
+type Change struct {
+	Kind         ChangeKind
+	Schema       string
+	Table        string
+	Column       string
+	Old          string
+	New          string
+	BreaksGo     bool
+	BreaksPython bool
+	Reason       string
+}
+
+func (c *Change) IsBreaking() bool {
+	return c.BreaksGo || c.BreaksPython
+}
+
+func (c *Change) String() string {
+	text := ""
+	if c.IsBreaking() {
+		text += "! "
+	} else {
+		text += "  "
+	}
+
+	text += c.Schema + "." + c.Table
+	if c.Column != "" {
+		text += "." + c.Column
+	}
+	text += ": " + string(c.Kind)
+	if c.Old != "" || c.New != "" {
+		text += fmt.Sprintf(" (%s -> %s)", valueOrNone(c.Old), valueOrNone(c.New))
+	}
+	if c.IsBreaking() {
+		text += " [breaks " + c.Reason + "]"
+	}
+	return text
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func describeFk(fk *metadata.ForeignKeyTarget) string {
+	if fk == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s.%s", fk.Schema, fk.Table, fk.Column)
+}
+
+// describeConstraint spells out a constraint, referential actions included
+func describeConstraint(constraint *metadata.Constraint) string {
+	text := constraint.Type + " (" + strings.Join(constraint.Columns, ", ") + ")"
+	if constraint.Type == metadata.ForeignKeyConstraint {
+		text += fmt.Sprintf(" REFERENCES %s.%s (%s)", constraint.RefSchema, constraint.RefTable,
+			strings.Join(constraint.RefColumns, ", "))
+		if constraint.OnDelete != "" {
+			text += " ON DELETE " + constraint.OnDelete
+		}
+		if constraint.OnUpdate != "" {
+			text += " ON UPDATE " + constraint.OnUpdate
+		}
+	}
+	return text
+}
+
+func describeIndex(index *metadata.Index) string {
+	if index.IsUnique {
+		return "UNIQUE (" + strings.Join(index.Columns, ", ") + ")"
+	}
+	return "(" + strings.Join(index.Columns, ", ") + ")"
+}
+
+// describeType names the type of a column, user defined and array types
+// included, along with its modifiers when asked to
+func describeType(col *metadata.Column, modifiers bool) string {
+	if col.IsArray() && col.ElementType != "" {
+		element := col.ElementType
+		if element == "USER-DEFINED" {
+			element = col.UdtSchema + "." + col.ElementUdtName()
+		} else if modifiers && col.FormattedType != "" {
+			element = col.FormattedType
+		}
+		return element + strings.Repeat("[]", max(col.ArrayDims, 1))
+	}
+	if col.Datatype == "USER-DEFINED" && col.UdtName != "" {
+		return col.UdtSchema + "." + col.UdtName
+	}
+	if modifiers && col.FormattedType != "" {
+		return col.FormattedType
+	}
+	return col.Datatype
+}
+
+func describeNullable(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+// apiTypes holds the go and python types generated for a column
+type apiTypes struct {
+	Go     string
+	Python string
+}
+
+// typesOf resolves the types of a column the way generation does, overrides
+// of the config included
+func typesOf(dbms string, meta *metadata.Metadata, config *config.Config, table *metadata.Table, col *metadata.Column) (apiTypes, error) {
+	goType, err := metago.GoFieldType(dbms, meta, config, table, col)
+	if err != nil {
+		return apiTypes{}, err
+	}
+	pythonType, err := metapy.PythonFieldType(dbms, meta, config, table, col)
+	if err != nil {
+		return apiTypes{}, err
+	}
+	return apiTypes{Go: goType, Python: pythonType}, nil
+}
+
+// typeChange fills the breaking flags of a change by comparing the go and
+// python types generated for the old and new column
+func typeChange(change Change, oldTypes apiTypes, newTypes apiTypes) Change {
+	reasons := make([]string, 0)
+	if oldTypes.Go != newTypes.Go {
+		change.BreaksGo = true
+		reasons = append(reasons, fmt.Sprintf("Go: %s -> %s", oldTypes.Go, newTypes.Go))
+	}
+	if oldTypes.Python != newTypes.Python {
+		change.BreaksPython = true
+		reasons = append(reasons, fmt.Sprintf("Python: %s -> %s", oldTypes.Python, newTypes.Python))
+	}
+	change.Reason = strings.Join(reasons, "; ")
+	return change
+}
+
+func hasAutoIncrement(table *metadata.Table) bool {
+	for i := range table.Columns {
+		if table.Columns[i].IsAutoIncrement {
+			return true
+		}
+	}
+	return false
+}
+
+func findTable(meta *metadata.Metadata, schema string, name string) *metadata.Table {
+	for i := range meta.Tables {
+		if meta.Tables[i].Schema == schema && meta.Tables[i].Name == name {
+			return &meta.Tables[i]
+		}
+	}
+	return nil
+}
+
+// typeName is the name of the struct generated for a table, and the prefix
+// of its functions
+func typeName(meta *metadata.Metadata, table *metadata.Table) string {
+	return metadata.ToPascalCase(meta.TableBaseName(table))
+}
+
+func diffColumn(dbms string, config *config.Config, oldMeta *metadata.Metadata, newMeta *metadata.Metadata, oldTable *metadata.Table, newTable *metadata.Table, oldCol *metadata.Column, newCol *metadata.Column) ([]Change, error) {
+	changes := make([]Change, 0)
+	base := Change{Schema: newTable.Schema, Table: newTable.Name, Column: newCol.Name}
+
+	oldTypes, err := typesOf(dbms, oldMeta, config, oldTable, oldCol)
+	if err != nil {
+		return nil, err
+	}
+
+	// snapshots taken before modifiers were kept compare on the bare types
+	modifiers := oldCol.FormattedType != "" && newCol.FormattedType != ""
+	if describeType(oldCol, modifiers) != describeType(newCol, modifiers) {
+		change := base
+		change.Kind = TypeChanged
+		change.Old = describeType(oldCol, modifiers)
+		change.New = describeType(newCol, modifiers)
+		// compare with the old nullability, so a flip is only reported once
+		sameNullability := *newCol
+		sameNullability.Nullable = oldCol.Nullable
+		retyped, err := typesOf(dbms, newMeta, config, newTable, &sameNullability)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChange(change, oldTypes, retyped))
+	}
+
+	if oldCol.Nullable != newCol.Nullable {
+		change := base
+		change.Kind = NullabilityChanged
+		change.Old = describeNullable(oldCol.Nullable)
+		change.New = describeNullable(newCol.Nullable)
+		retyped := *oldCol
+		retyped.Nullable = newCol.Nullable
+		nullTypes, err := typesOf(dbms, oldMeta, config, oldTable, &retyped)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChange(change, oldTypes, nullTypes))
+	}
+
+	if oldCol.IsPrimaryKey != newCol.IsPrimaryKey {
+		// the key columns are the arguments of SelectXByPK and ExistsX, and
+		// the where clause of UpdateX/DeleteX
+		change := base
+		change.Kind = PrimaryKeyChanged
+		change.Old = fmt.Sprintf("%t", oldCol.IsPrimaryKey)
+		change.New = fmt.Sprintf("%t", newCol.IsPrimaryKey)
+		change.BreaksGo = true
+		change.Reason = "Go: Select" + typeName(newMeta, newTable) + "ByPK"
+		if !hasAutoIncrement(oldTable) && !hasAutoIncrement(newTable) {
+			change.Reason += " and Exists" + typeName(newMeta, newTable)
+		}
+		change.Reason += " change signature"
+		changes = append(changes, change)
+	}
+
+	if describeFk(oldCol.FkTarget) != describeFk(newCol.FkTarget) {
+		change := base
+		change.Kind = ForeignKeyChanged
+		change.Old = describeFk(oldCol.FkTarget)
+		change.New = describeFk(newCol.FkTarget)
+		changes = append(changes, change)
+	}
+
+	if oldCol.IsAutoIncrement != newCol.IsAutoIncrement {
+		change := base
+		change.Kind = AutoIncrementChanged
+		change.Old = fmt.Sprintf("%t", oldCol.IsAutoIncrement)
+		change.New = fmt.Sprintf("%t", newCol.IsAutoIncrement)
+		if newCol.IsAutoIncrement && !hasAutoIncrement(oldTable) {
+			// tables with an auto increment column get no ExistsX
+			change.BreaksGo = true
+			change.Reason = "Go: Exists" + typeName(newMeta, newTable) + " is no longer generated"
+		} else if !newCol.IsAutoIncrement {
+			change.BreaksGo = true
+			change.Reason = "Go: Insert" + typeName(newMeta, newTable) + " no longer fills in " +
+				metadata.ToPascalCase(newCol.Name)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func diffTable(dbms string, config *config.Config, oldMeta *metadata.Metadata, newMeta *metadata.Metadata, oldTable *metadata.Table, newTable *metadata.Table) ([]Change, error) {
+	changes := make([]Change, 0)
+	base := Change{Schema: newTable.Schema, Table: newTable.Name}
+
+	for i := range newTable.Columns {
+		newCol := &newTable.Columns[i]
+		oldCol := oldTable.SearchColumnByName(newCol.Name)
+		if oldCol == nil {
+			change := base
+			change.Kind = ColumnAdded
+			change.Column = newCol.Name
+			change.New = describeType(newCol, true)
+			// dataclass fields have no defaults, so the constructor gains a required argument
+			change.BreaksPython = true
+			change.Reason = "Python: " + typeName(newMeta, newTable) + "() requires " + newCol.Name
+			changes = append(changes, change)
+			continue
+		}
+
+		columnChanges, err := diffColumn(dbms, config, oldMeta, newMeta, oldTable, newTable, oldCol, newCol)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, columnChanges...)
+	}
+
+	for i := range oldTable.Columns {
+		oldCol := &oldTable.Columns[i]
+		if newTable.SearchColumnByName(oldCol.Name) == nil {
+			change := base
+			change.Kind = ColumnRemoved
+			change.Column = oldCol.Name
+			change.Old = describeType(oldCol, true)
+			change.BreaksGo = true
+			change.BreaksPython = true
+			change.Reason = "Go/Python: field " + metadata.ToPascalCase(oldCol.Name) + " is gone"
+			changes = append(changes, change)
+		}
+	}
+
+	keyChanges, err := diffKeys(dbms, oldMeta, newMeta, oldTable, newTable)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, keyChanges...)
+
+	return changes, nil
+}
+
+func findConstraint(table *metadata.Table, name string) *metadata.Constraint {
+	for i := range table.Constraints {
+		if table.Constraints[i].Name == name {
+			return &table.Constraints[i]
+		}
+	}
+	return nil
+}
+
+func findIndex(table *metadata.Table, name string) *metadata.Index {
+	for i := range table.Indexes {
+		if table.Indexes[i].Name == name {
+			return &table.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// withConstraint returns a copy of a table whose constraint of the given name
+// is replaced by another one, or dropped when there is none
+func withConstraint(table *metadata.Table, name string, constraint *metadata.Constraint) *metadata.Table {
+	copied := *table
+	copied.Constraints = make([]metadata.Constraint, 0)
+	for i := range table.Constraints {
+		if table.Constraints[i].Name != name {
+			copied.Constraints = append(copied.Constraints, table.Constraints[i])
+		}
+	}
+	if constraint != nil {
+		copied.Constraints = append(copied.Constraints, *constraint)
+	}
+	return &copied
+}
+
+// withIndex returns a copy of a table whose index of the given name is
+// replaced by another one, or dropped when there is none
+func withIndex(table *metadata.Table, name string, index *metadata.Index) *metadata.Table {
+	copied := *table
+	copied.Indexes = make([]metadata.Index, 0)
+	for i := range table.Indexes {
+		if table.Indexes[i].Name != name {
+			copied.Indexes = append(copied.Indexes, table.Indexes[i])
+		}
+	}
+	if index != nil {
+		copied.Indexes = append(copied.Indexes, *index)
+	}
+	return &copied
+}
+
+// diffKeys lists the unique and foreign key constraints and the indexes
+// added, removed or changed between two versions of a table. The primary key
+// is left to PrimaryKeyChanged. Finders, upserts and relations are generated
+// out of these keys, so the changes are applied one after the other to the
+// old table, and one breaks go code when it drops some of the functions
+// missing from the new table.
+func diffKeys(dbms string, oldMeta *metadata.Metadata, newMeta *metadata.Metadata, oldTable *metadata.Table, newTable *metadata.Table) ([]Change, error) {
+	changes := make([]Change, 0)
+	base := Change{Schema: newTable.Schema, Table: newTable.Name}
+
+	oldFuncs, err := metago.KeyFuncNames(dbms, oldMeta, oldTable)
+	if err != nil {
+		return nil, err
+	}
+	newFuncs, err := metago.KeyFuncNames(dbms, newMeta, newTable)
+	if err != nil {
+		return nil, err
+	}
+	gone := make([]string, 0)
+	for i := range oldFuncs {
+		if !metadata.ContainsString(newFuncs, oldFuncs[i]) {
+			gone = append(gone, oldFuncs[i])
+		}
+	}
+	// the changes are applied to the old table along with the new columns,
+	// which added keys may refer to
+	current := &metadata.Table{}
+	*current = *oldTable
+	current.Columns = append([]metadata.Column{}, oldTable.Columns...)
+	for i := range newTable.Columns {
+		if oldTable.SearchColumnByName(newTable.Columns[i].Name) == nil {
+			current.Columns = append(current.Columns, newTable.Columns[i])
+		}
+	}
+	currentFuncs, err := metago.KeyFuncNames(dbms, oldMeta, current)
+	if err != nil {
+		return nil, err
+	}
+	addChange := func(change Change, applied *metadata.Table) error {
+		funcs, err := metago.KeyFuncNames(dbms, oldMeta, applied)
+		if err != nil {
+			return err
+		}
+		dropped := make([]string, 0)
+		for i := range gone {
+			if metadata.ContainsString(currentFuncs, gone[i]) && !metadata.ContainsString(funcs, gone[i]) {
+				dropped = append(dropped, gone[i])
+			}
+		}
+		current, currentFuncs = applied, funcs
+		if len(dropped) > 0 {
+			change.BreaksGo = true
+			change.Reason = "Go: " + strings.Join(dropped, ", ") + " no longer generated"
+		}
+		changes = append(changes, change)
+		return nil
+	}
+
+	for i := range newTable.Constraints {
+		newConstraint := &newTable.Constraints[i]
+		if newConstraint.Type == metadata.PrimaryKeyConstraint {
+			continue
+		}
+		oldConstraint := findConstraint(oldTable, newConstraint.Name)
+		change := base
+		change.Column = newConstraint.Name
+		change.New = describeConstraint(newConstraint)
+		if oldConstraint == nil {
+			change.Kind = ConstraintAdded
+		} else if describeConstraint(oldConstraint) != change.New {
+			change.Kind = ConstraintChanged
+			change.Old = describeConstraint(oldConstraint)
+		} else {
+			continue
+		}
+		err := addChange(change, withConstraint(current, newConstraint.Name, newConstraint))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range oldTable.Constraints {
+		oldConstraint := &oldTable.Constraints[i]
+		if oldConstraint.Type == metadata.PrimaryKeyConstraint || findConstraint(newTable, oldConstraint.Name) != nil {
+			continue
+		}
+		change := base
+		change.Kind = ConstraintRemoved
+		change.Column = oldConstraint.Name
+		change.Old = describeConstraint(oldConstraint)
+		err := addChange(change, withConstraint(current, oldConstraint.Name, nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range newTable.Indexes {
+		newIndex := &newTable.Indexes[i]
+		if newIndex.IsPrimary {
+			continue
+		}
+		oldIndex := findIndex(oldTable, newIndex.Name)
+		change := base
+		change.Column = newIndex.Name
+		change.New = describeIndex(newIndex)
+		if oldIndex == nil {
+			change.Kind = IndexAdded
+		} else if describeIndex(oldIndex) != change.New {
+			change.Kind = IndexChanged
+			change.Old = describeIndex(oldIndex)
+		} else {
+			continue
+		}
+		err := addChange(change, withIndex(current, newIndex.Name, newIndex))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range oldTable.Indexes {
+		oldIndex := &oldTable.Indexes[i]
+		if oldIndex.IsPrimary || findIndex(newTable, oldIndex.Name) != nil {
+			continue
+		}
+		change := base
+		change.Kind = IndexRemoved
+		change.Column = oldIndex.Name
+		change.Old = describeIndex(oldIndex)
+		err := addChange(change, withIndex(current, oldIndex.Name, nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// renamed is the change of the name generated for a table or enum, which
+// the code using it has to follow
+func renamed(schema string, name string, oldName string, newName string) Change {
+	return Change{
+		Kind:         TypeRenamed,
+		Schema:       schema,
+		Table:        name,
+		Old:          oldName,
+		New:          newName,
+		BreaksGo:     true,
+		BreaksPython: true,
+		Reason:       "Go/Python: " + oldName + " is renamed " + newName,
+	}
+}
+
+// Diff lists the changes that turn the old snapshot into the new one. The
+// type overrides of the config, if any, are taken into account to tell which
+// changes break the generated code.
+func Diff(oldSnapshot *metadata.Snapshot, newSnapshot *metadata.Snapshot, config *config.Config) ([]Change, error) {
+	if oldSnapshot.DBMS != newSnapshot.DBMS {
+		return nil, fmt.Errorf("snapshots come from different DBMS: %s and %s", oldSnapshot.DBMS, newSnapshot.DBMS)
+	}
+	dbms := newSnapshot.DBMS
+	oldMeta := &oldSnapshot.Metadata
+	newMeta := &newSnapshot.Metadata
+
+	changes := make([]Change, 0)
+	for i := range newMeta.Tables {
+		newTable := &newMeta.Tables[i]
+		oldTable := findTable(oldMeta, newTable.Schema, newTable.Name)
+		if oldTable == nil {
+			changes = append(changes, Change{Kind: TableAdded, Schema: newTable.Schema, Table: newTable.Name})
+			continue
+		}
+
+		if oldName, newName := typeName(oldMeta, oldTable), typeName(newMeta, newTable); oldName != newName {
+			changes = append(changes, renamed(newTable.Schema, newTable.Name, oldName, newName))
+		}
+		tableChanges, err := diffTable(dbms, config, oldMeta, newMeta, oldTable, newTable)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+	}
+
+	for i := range oldMeta.Tables {
+		oldTable := &oldMeta.Tables[i]
+		if findTable(newMeta, oldTable.Schema, oldTable.Name) == nil {
+			changes = append(changes, Change{
+				Kind:         TableRemoved,
+				Schema:       oldTable.Schema,
+				Table:        oldTable.Name,
+				BreaksGo:     true,
+				BreaksPython: true,
+				Reason:       "Go/Python: " + typeName(oldMeta, oldTable) + " and its functions are gone",
+			})
+		}
+	}
+
+	changes = append(changes, diffEnums(oldMeta, newMeta)...)
+
+	return changes, nil
+}
+
+func diffEnums(oldMeta *metadata.Metadata, newMeta *metadata.Metadata) []Change {
+	changes := make([]Change, 0)
+	for i := range newMeta.Enums {
+		newEnum := &newMeta.Enums[i]
+		oldEnum := oldMeta.SearchEnum(newEnum.Schema, newEnum.Name)
+		if oldEnum == nil {
+			changes = append(changes, Change{Kind: EnumAdded, Schema: newEnum.Schema, Table: newEnum.Name})
+			continue
+		}
+		if oldName, newName := oldMeta.EnumTypeName(oldEnum), newMeta.EnumTypeName(newEnum); oldName != newName {
+			changes = append(changes, renamed(newEnum.Schema, newEnum.Name, oldName, newName))
+		}
+
+		for j := range newEnum.Labels {
+			if !metadata.ContainsString(oldEnum.Labels, newEnum.Labels[j]) {
+				changes = append(changes, Change{
+					Kind:   EnumLabelAdded,
+					Schema: newEnum.Schema,
+					Table:  newEnum.Name,
+					Column: newEnum.Labels[j],
+				})
+			}
+		}
+		for j := range oldEnum.Labels {
+			if !metadata.ContainsString(newEnum.Labels, oldEnum.Labels[j]) {
+				changes = append(changes, Change{
+					Kind:         EnumLabelRemoved,
+					Schema:       oldEnum.Schema,
+					Table:        oldEnum.Name,
+					Column:       oldEnum.Labels[j],
+					BreaksGo:     true,
+					BreaksPython: true,
+					Reason:       "Go/Python: the constant of " + oldEnum.Labels[j] + " is gone",
+				})
+			}
+		}
+	}
+
+	for i := range oldMeta.Enums {
+		oldEnum := &oldMeta.Enums[i]
+		if newMeta.SearchEnum(oldEnum.Schema, oldEnum.Name) == nil {
+			changes = append(changes, Change{
+				Kind:         EnumRemoved,
+				Schema:       oldEnum.Schema,
+				Table:        oldEnum.Name,
+				BreaksGo:     true,
+				BreaksPython: true,
+				Reason:       "Go/Python: " + oldMeta.EnumTypeName(oldEnum) + " is gone",
+			})
+		}
+	}
+
+	return changes
+}
+
+// DiffFiles compares two snapshot files
+func DiffFiles(oldFile string, newFile string, config *config.Config) ([]Change, error) {
+	oldSnapshot, err := metadata.ReadSnapshot(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newSnapshot, err := metadata.ReadSnapshot(newFile)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(oldSnapshot, newSnapshot, config)
+}
@@ -0,0 +1,184 @@
+package metadiff
+
+import (
+	"dto-gen/config"
+	"dto-gen/metadata"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func snapshot(tables ...metadata.Table) *metadata.Snapshot {
+	return &metadata.Snapshot{
+		Version:  metadata.SnapshotVersion,
+		DBMS:     "PostgreSQL",
+		Metadata: metadata.Metadata{Database: "shop", Tables: tables},
+	}
+}
+
+func customer() metadata.Table {
+	return metadata.Table{
+		Schema: "public",
+		Name:   "customer",
+		Columns: []metadata.Column{
+			{Ordinal: 1, Name: "id", Datatype: "integer", FormattedType: "integer", UdtName: "int4", IsPrimaryKey: true, IsAutoIncrement: true},
+			{Ordinal: 2, Name: "email", Datatype: "character varying", FormattedType: "character varying(40)", UdtName: "varchar"},
+			{Ordinal: 3, Name: "visits", Datatype: "integer", FormattedType: "integer", UdtName: "int4"},
+		},
+		Constraints: []metadata.Constraint{
+			{Name: "customer_pkey", Type: metadata.PrimaryKeyConstraint, Columns: []string{"id"}},
+			{Name: "customer_email_key", Type: metadata.UniqueConstraint, Columns: []string{"email"}},
+		},
+		Indexes: []metadata.Index{
+			{Name: "customer_pkey", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+			{Name: "customer_email_key", Columns: []string{"email"}, IsUnique: true},
+		},
+	}
+}
+
+// gist sums up a change as the tests expect it
+func (c *Change) gist() string {
+	return fmt.Sprintf("%s %s.%s go:%t python:%t", c.Kind, c.Table, c.Column, c.BreaksGo, c.BreaksPython)
+}
+
+func TestDiff(t *testing.T) {
+	added := customer()
+	added.Columns = append(added.Columns, metadata.Column{Ordinal: 4, Name: "name", Datatype: "text", FormattedType: "text", UdtName: "text"})
+
+	removed := customer()
+	removed.Columns = removed.Columns[:2]
+
+	longer := customer()
+	longer.Columns[1].FormattedType = "character varying(80)"
+
+	bigger := customer()
+	bigger.Columns[2].Datatype = "bigint"
+	bigger.Columns[2].FormattedType = "bigint"
+	bigger.Columns[2].UdtName = "int8"
+
+	nullable := customer()
+	nullable.Columns[2].Nullable = true
+
+	unformatted := customer()
+	for i := range unformatted.Columns {
+		unformatted.Columns[i].FormattedType = ""
+	}
+
+	noUnique := customer()
+	noUnique.Constraints = noUnique.Constraints[:1]
+	noUnique.Indexes = noUnique.Indexes[:1]
+
+	indexed := customer()
+	indexed.Indexes = append(indexed.Indexes, metadata.Index{Name: "customer_visits", Columns: []string{"visits"}})
+
+	billing := customer()
+	billing.Schema = "billing"
+
+	tests := []struct {
+		name    string
+		old     *metadata.Snapshot
+		new     *metadata.Snapshot
+		changes []string
+	}{
+		{"same", snapshot(customer()), snapshot(customer()), nil},
+		{"table added", snapshot(), snapshot(customer()), []string{"table added customer. go:false python:false"}},
+		{"table removed", snapshot(customer()), snapshot(), []string{"table removed customer. go:true python:true"}},
+		{"same name in another schema", snapshot(customer()), snapshot(customer(), billing), []string{
+			"type renamed customer. go:true python:true",
+			"table added customer. go:false python:false",
+		}},
+		{"column added", snapshot(customer()), snapshot(added), []string{"column added customer.name go:false python:true"}},
+		{"column removed", snapshot(customer()), snapshot(removed), []string{"column removed customer.visits go:true python:true"}},
+		{"length", snapshot(customer()), snapshot(longer), []string{"type changed customer.email go:false python:false"}},
+		{"type", snapshot(customer()), snapshot(bigger), []string{"type changed customer.visits go:true python:false"}},
+		{"nullability", snapshot(customer()), snapshot(nullable), []string{"nullability changed customer.visits go:true python:true"}},
+		{"modifiers not recorded", snapshot(unformatted), snapshot(longer), nil},
+		{"unique constraint removed", snapshot(customer()), snapshot(noUnique), []string{
+			// the upsert goes with the constraint, the finder with the index
+			"constraint removed customer.customer_email_key go:true python:false",
+			"index removed customer.customer_email_key go:true python:false",
+		}},
+		{"unique constraint added", snapshot(noUnique), snapshot(customer()), []string{
+			"constraint added customer.customer_email_key go:false python:false",
+			"index added customer.customer_email_key go:false python:false",
+		}},
+		{"index added", snapshot(customer()), snapshot(indexed), []string{"index added customer.customer_visits go:false python:false"}},
+		{"index removed", snapshot(indexed), snapshot(customer()), []string{"index removed customer.customer_visits go:true python:false"}},
+	}
+	for _, tt := range tests {
+		changes, err := Diff(tt.old, tt.new, nil)
+		if err != nil {
+			t.Fatalf("%s: Diff() error: %v", tt.name, err)
+		}
+		got := make([]string, 0)
+		for i := range changes {
+			got = append(got, changes[i].gist())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.changes, "\n") {
+			t.Errorf("%s: changes\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.changes, "\n"))
+		}
+	}
+}
+
+func TestDiffOverrides(t *testing.T) {
+	bigger := customer()
+	bigger.Columns[2].Datatype = "bigint"
+	bigger.Columns[2].FormattedType = "bigint"
+	bigger.Columns[2].UdtName = "int8"
+
+	retyped := customer()
+	retyped.Columns[0].Datatype = "text"
+	retyped.Columns[0].FormattedType = "text"
+	retyped.Columns[0].UdtName = "text"
+
+	tests := []struct {
+		name      string
+		new       metadata.Table
+		overrides config.Overrides
+		change    string
+	}{
+		{
+			name:      "no overrides",
+			new:       bigger,
+			overrides: config.Overrides{},
+			change:    "type changed customer.visits go:true python:false",
+		},
+		{
+			name: "type override",
+			new:  bigger,
+			overrides: config.Overrides{Types: map[string]config.TypeOverride{
+				"bigint": {GoType: "int"},
+			}},
+			change: "type changed customer.visits go:false python:false",
+		},
+		{
+			name: "column override",
+			new:  retyped,
+			overrides: config.Overrides{Columns: map[string]config.TypeOverride{
+				"customer.id": {GoType: "CustomerID", PythonType: "CustomerId"},
+			}},
+			change: "type changed customer.id go:false python:false",
+		},
+		{
+			name: "go only column override",
+			new:  retyped,
+			overrides: config.Overrides{Columns: map[string]config.TypeOverride{
+				"public.customer.id": {GoType: "CustomerID"},
+			}},
+			change: "type changed customer.id go:false python:true",
+		},
+	}
+	for _, tt := range tests {
+		changes, err := Diff(snapshot(customer()), snapshot(tt.new), &config.Config{Overrides: tt.overrides})
+		if err != nil {
+			t.Fatalf("%s: Diff() error: %v", tt.name, err)
+		}
+		if len(changes) != 1 || changes[0].gist() != tt.change {
+			got := make([]string, 0)
+			for i := range changes {
+				got = append(got, changes[i].gist())
+			}
+			t.Errorf("%s: changes %q, want %q", tt.name, got, tt.change)
+		}
+	}
+}
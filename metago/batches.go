@@ -0,0 +1,92 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+)
+
+// ======================================================================================
+//     Batches
+// ======================================================================================
+
+// generateSendBatch adds SendBatch to the connector, which sends the queries
+// queued by the QueueX functions in one round trip and reads their results
+// back
+func generateSendBatch(source *GoSourceFile) {
+    if !dialect.isPostgres() {
+        return
+    }
+
+    sendFunc := GoFuncs{
+        Name:    "SendBatch",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    sendFunc.addArg(ctxArg())
+    sendFunc.addArg(connArg())
+    sendFunc.addArg(GoFuncArg{Name: "batch", Type: "pgx.Batch", IsPointer: true})
+    sendFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    // closing the results runs the callbacks of the queued queries in order,
+    // stopping at the first error
+    sendFunc.addLine("err := conn.SendBatch(ctx, batch).Close()")
+    sendFunc.addLine("if err != nil {")
+    sendFunc.addLine("    return fmt.Errorf(\"failed to perform batch: %w\", err)")
+    sendFunc.addLine("}")
+    sendFunc.addLine("return nil")
+    source.addFunc(sendFunc)
+}
+
+// generateQueueFuncs adds QueueInsertX, QueueUpdateX and QueueDeleteX, which
+// queue the queries of InsertX, UpdateX and DeleteX on a pgx.Batch. Auto
+// increment ids are filled in once the batch is sent.
+func generateQueueFuncs(table *metadata.Table, source *GoSourceFile) error {
+    if !dialect.isPostgres() {
+        return nil
+    }
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+
+    newQueueFunc := func(name string) GoFuncs {
+        queueFunc := GoFuncs{
+            Name:    name + tableNamePascalCase,
+            Args:    make([]GoFuncArg, 0),
+            Returns: make([]GoFuncReturn, 0),
+            Lines:   make([]string, 0),
+        }
+        queueFunc.addArg(GoFuncArg{Name: "batch", Type: "pgx.Batch", IsPointer: true})
+        queueFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
+        return queueFunc
+    }
+
+    insertFunc := newQueueFunc("QueueInsert")
+    addInsertQuery(&insertFunc, table)
+    if autoIncrementCol := autoIncrementColumn(table); autoIncrementCol != nil {
+        autoIncrementField := tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+        insertFunc.addLine("batch.Queue(query" + insertArgs(table, tableNameCamelCase) + ").QueryRow(func(row pgx.Row) error {")
+        insertFunc.addLine("    err := row.Scan(&" + autoIncrementField + ")")
+        insertFunc.addLine("    if err != nil {")
+        insertFunc.addLine("        return fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("    }")
+        insertFunc.addLine("    return nil")
+        insertFunc.addLine("})")
+    } else {
+        insertFunc.addLine("batch.Queue(query" + insertArgs(table, tableNameCamelCase) + ")")
+    }
+    source.addFunc(insertFunc)
+
+    if len(primaryKeyColumns(table)) == 0 {
+        return nil
+    }
+
+    updateFunc := newQueueFunc("QueueUpdate")
+    addUpdateQuery(&updateFunc, table)
+    updateFunc.addLine("batch.Queue(query" + updateArgs(table, tableNameCamelCase) + ")")
+    source.addFunc(updateFunc)
+
+    deleteFunc := newQueueFunc("QueueDelete")
+    addDeleteQuery(&deleteFunc, table)
+    deleteFunc.addLine("batch.Queue(query" + deleteArgs(table, tableNameCamelCase) + ")")
+    source.addFunc(deleteFunc)
+
+    return nil
+}
@@ -0,0 +1,191 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+    "fmt"
+    "strings"
+)
+
+// ======================================================================================
+//     Bulk Inserts
+// ======================================================================================
+
+// bulkInsertColumns returns the columns bulk inserts write, auto increment
+// ones being left to the database, and the first auto increment column
+func bulkInsertColumns(table *metadata.Table) ([]*metadata.Column, *metadata.Column) {
+    var insertCols []*metadata.Column
+    var autoIncrementCol *metadata.Column
+    for i := range table.Columns {
+        if table.Columns[i].IsAutoIncrement {
+            if autoIncrementCol == nil {
+                autoIncrementCol = &table.Columns[i]
+            }
+            continue
+        }
+        insertCols = append(insertCols, &table.Columns[i])
+    }
+    return insertCols, autoIncrementCol
+}
+
+// generateCopyInsert loads rows with the COPY protocol, the fastest way to
+// insert many rows into PostgreSQL. Auto increment columns are left to the
+// database and not read back.
+func generateCopyInsert(table *metadata.Table, source *GoSourceFile) error {
+    if !dialect.isPostgres() {
+        return nil
+    }
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+
+    insertCols, _ := bulkInsertColumns(table)
+    if len(insertCols) == 0 {
+        return nil
+    }
+
+    copyFunc := GoFuncs{
+        Name:    "CopyInsert" + tableNamePascalCase,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    copyFunc.addArg(ctxArg())
+    copyFunc.addArg(connArg())
+    copyFunc.addArg(GoFuncArg{Name: "items", Type: "[]" + tableNamePascalCase, IsPointer: false})
+    copyFunc.addReturn(GoFuncReturn{Type: "int64", IsPointer: false})
+    copyFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    columnNames := make([]string, 0)
+    args := make([]string, 0)
+    for i := range insertCols {
+        columnNames = append(columnNames, "\""+insertCols[i].Name+"\"")
+        args = append(args, bindValue(table, insertCols[i], "items[i]."+metadata.ToPascalCase(insertCols[i].Name)))
+    }
+
+    copyFunc.addLine("columns := []string{" + strings.Join(columnNames, ", ") + "}")
+    // pgx quotes the identifiers itself
+    identifier := "\"" + table.Name + "\""
+    if table.Schema != "" {
+        identifier = "\"" + table.Schema + "\", " + identifier
+    }
+    copyFunc.addLine("count, err := conn.CopyFrom(ctx, pgx.Identifier{" + identifier + "}, columns,")
+    copyFunc.addLine("    pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {")
+    copyFunc.addLine("        return []any{" + strings.Join(args, ", ") + "}, nil")
+    copyFunc.addLine("    }))")
+    copyFunc.addLine("if err != nil {")
+    copyFunc.addLine("    return 0, fmt.Errorf(\"failed to perform copy: %w\", err)")
+    copyFunc.addLine("}")
+    copyFunc.addLine("return count, nil")
+
+    source.addFunc(copyFunc)
+    return nil
+}
+
+// generateInsertMany inserts rows with multi-row VALUES, in as many statements
+// as the bind parameter limit of the DBMS requires. Auto increment keys are
+// read back through RETURNING, so without it the function is only generated
+// for tables having none. RETURNING doesn't promise to follow the order of the
+// VALUES list, so the keys are returned as they come rather than filled into
+// the items.
+func generateInsertMany(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+
+    insertCols, autoIncrementCol := bulkInsertColumns(table)
+    if len(insertCols) == 0 || (autoIncrementCol != nil && !dialect.HasReturning) {
+        return nil
+    }
+    if !metadata.ContainsString(source.Imports, "strings") {
+        source.addImport("strings")
+    }
+
+    insertFunc := GoFuncs{
+        Name:    "InsertMany" + tableNamePascalCase,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    insertFunc.addArg(ctxArg())
+    insertFunc.addArg(connArg())
+    insertFunc.addArg(GoFuncArg{Name: "items", Type: "[]" + tableNamePascalCase, IsPointer: false})
+    failure := "return "
+    if autoIncrementCol != nil {
+        insertFunc.addReturn(GoFuncReturn{Type: "[]" + fieldGoType(table, autoIncrementCol), IsPointer: false})
+        failure = "return nil, "
+    }
+    insertFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    columnNames := make([]string, 0)
+    args := make([]string, 0)
+    for i := range insertCols {
+        columnNames = append(columnNames, quotedIdent(insertCols[i].Name))
+        args = append(args, bindValue(table, insertCols[i], "batch[i]."+metadata.ToPascalCase(insertCols[i].Name)))
+    }
+
+    insertFunc.addLine(fmt.Sprintf("const columnCount = %d", len(insertCols)))
+    insertFunc.addLine(fmt.Sprintf("const batchSize = %d / columnCount", dialect.MaxParams))
+    if autoIncrementCol != nil {
+        insertFunc.addLine("keys := make([]" + fieldGoType(table, autoIncrementCol) + ", 0, len(items))")
+    }
+    insertFunc.addLine("for start := 0; start < len(items); start += batchSize {")
+    insertFunc.addLine("    batch := items[start:min(start+batchSize, len(items))]")
+    insertFunc.addLine("    var query strings.Builder")
+    insertFunc.addLine("    query.WriteString(\"INSERT INTO " + quotedTable(table) + " (" + strings.Join(columnNames, ", ") + ") VALUES \")")
+    insertFunc.addLine("    args := make([]any, 0, len(batch)*columnCount)")
+    insertFunc.addLine("    for i := range batch {")
+    insertFunc.addLine("        if i > 0 {")
+    insertFunc.addLine("            query.WriteString(\", \")")
+    insertFunc.addLine("        }")
+    if dialect.isPostgres() {
+        // numbered placeholders continue from the previous row
+        placeholders := make([]string, 0)
+        offsets := make([]string, 0)
+        for i := range insertCols {
+            placeholders = append(placeholders, "$%d")
+            offsets = append(offsets, fmt.Sprintf("len(args)+%d", i+1))
+        }
+        insertFunc.addLine("        fmt.Fprintf(&query, \"(" + strings.Join(placeholders, ", ") + ")\", " + strings.Join(offsets, ", ") + ")")
+    } else {
+        placeholders := make([]string, 0)
+        for i := range insertCols {
+            placeholders = append(placeholders, dialect.placeholder(i+1))
+        }
+        insertFunc.addLine("        query.WriteString(\"(" + strings.Join(placeholders, ", ") + ")\")")
+    }
+    insertFunc.addLine("        args = append(args, " + strings.Join(args, ", ") + ")")
+    insertFunc.addLine("    }")
+
+    if autoIncrementCol != nil {
+        insertFunc.addLine("    query.WriteString(\" RETURNING " + quotedIdent(autoIncrementCol.Name) + "\")\n")
+        insertFunc.addLine("    rows, err := conn." + dialect.QueryFunc + "(ctx, query.String(), args...)")
+        insertFunc.addLine("    if err != nil {")
+        insertFunc.addLine("        " + failure + "fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("    }")
+        insertFunc.addLine("    for rows.Next() {")
+        insertFunc.addLine("        var key " + fieldGoType(table, autoIncrementCol))
+        insertFunc.addLine("        err = rows.Scan(&key)")
+        insertFunc.addLine("        if err != nil {")
+        insertFunc.addLine("            rows.Close()")
+        insertFunc.addLine("            " + failure + "fmt.Errorf(\"error scanning row: %w\", err)")
+        insertFunc.addLine("        }")
+        insertFunc.addLine("        keys = append(keys, key)")
+        insertFunc.addLine("    }")
+        insertFunc.addLine("    rows.Close()")
+        insertFunc.addLine("    err = rows.Err()")
+        insertFunc.addLine("    if err != nil {")
+        insertFunc.addLine("        " + failure + "fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("    }")
+    } else {
+        insertFunc.addLine("")
+        insertFunc.addLine("    _, err := conn." + dialect.ExecFunc + "(ctx, query.String(), args...)")
+        insertFunc.addLine("    if err != nil {")
+        insertFunc.addLine("        return fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("    }")
+    }
+    insertFunc.addLine("}")
+    if autoIncrementCol != nil {
+        insertFunc.addLine("return keys, nil")
+    } else {
+        insertFunc.addLine("return nil")
+    }
+
+    source.addFunc(insertFunc)
+    return nil
+}
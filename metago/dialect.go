@@ -0,0 +1,354 @@
+package metago
+
+import (
+    "dto-gen/config"
+    "dto-gen/metadata"
+    "dto-gen/mysql"
+    "dto-gen/pgsql"
+    "dto-gen/sqlite"
+    "fmt"
+    "strings"
+)
+
+// ======================================================================================
+//     SQL Dialects
+// ======================================================================================
+
+// GoDialect describes how the generated code talks to a given DBMS: which
+// driver it uses, the connection/rows types it passes around and how it
+// writes placeholders and retrieves auto increment values.
+type GoDialect struct {
+    DBMS         string
+    Imports      []string
+    Driver       string
+    DriverName   string
+    ConnType     string
+    PoolImport   string
+    PoolType     string
+    RowsType     string
+    RowType      string
+    ResultImport string
+    ResultType   string
+    QueryFunc    string
+    QueryRowFunc string
+    ExecFunc     string
+    GoTypes      map[string]string
+    HasReturning bool
+    MaxParams    int
+}
+
+var postgresDialect = GoDialect{
+    DBMS:         "PostgreSQL",
+    Imports:      []string{"github.com/jackc/pgx/v5"},
+    Driver:       "",
+    DriverName:   "",
+    ConnType:     "pgx.Conn",
+    PoolImport:   "github.com/jackc/pgx/v5/pgxpool",
+    PoolType:     "pgxpool.Pool",
+    RowsType:     "pgx.Rows",
+    RowType:      "pgx.Row",
+    ResultImport: "github.com/jackc/pgx/v5/pgconn",
+    ResultType:   "pgconn.CommandTag",
+    QueryFunc:    "Query",
+    QueryRowFunc: "QueryRow",
+    ExecFunc:     "Exec",
+    GoTypes:      pgsql.PostgreSQLToGolangTypes,
+    HasReturning: true,
+    MaxParams:    65535,
+}
+
+var mysqlDialect = GoDialect{
+    DBMS:         "MySQL",
+    Imports:      []string{"database/sql"},
+    Driver:       "github.com/go-sql-driver/mysql",
+    DriverName:   "mysql",
+    ConnType:     "sql.DB",
+    PoolImport:   "",
+    PoolType:     "",
+    RowsType:     "*sql.Rows",
+    RowType:      "*sql.Row",
+    ResultImport: "",
+    ResultType:   "sql.Result",
+    QueryFunc:    "QueryContext",
+    QueryRowFunc: "QueryRowContext",
+    ExecFunc:     "ExecContext",
+    GoTypes:      mysql.MySQLToGolangTypes,
+    HasReturning: false,
+    MaxParams:    65535,
+}
+
+var sqliteDialect = GoDialect{
+    DBMS:         "SQLite",
+    Imports:      []string{"database/sql"},
+    Driver:       "modernc.org/sqlite",
+    DriverName:   "sqlite",
+    ConnType:     "sql.DB",
+    PoolImport:   "",
+    PoolType:     "",
+    RowsType:     "*sql.Rows",
+    RowType:      "*sql.Row",
+    ResultImport: "",
+    ResultType:   "sql.Result",
+    QueryFunc:    "QueryContext",
+    QueryRowFunc: "QueryRowContext",
+    ExecFunc:     "ExecContext",
+    GoTypes:      sqlite.SQLiteToGolangTypes,
+    HasReturning: false,
+    MaxParams:    32766,
+}
+
+// dialect used by the generators, selected by WriteGolang
+var dialect = &postgresDialect
+
+// metadata being generated, so column types can refer to its enums
+var sourceMetadata *metadata.Metadata
+
+func dialectFor(dbms string) (*GoDialect, error) {
+    switch dbms {
+    case "PostgreSQL":
+        return &postgresDialect, nil
+    case "MySQL", "MariaDB":
+        return &mysqlDialect, nil
+    case "SQLite":
+        return &sqliteDialect, nil
+    }
+    return nil, fmt.Errorf("unsupported DBMS for go generation: %s", dbms)
+}
+
+func (d *GoDialect) isPostgres() bool {
+    return d.DBMS == "PostgreSQL"
+}
+
+// connectionString builds the default connection string of the generated Connect func
+func (d *GoDialect) connectionString(connInfo *config.ConnectionInfo) string {
+    if d.isPostgres() {
+        return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+            connInfo.Host, connInfo.Port, connInfo.Username, connInfo.Password, connInfo.Database)
+    } else if d.DBMS == "SQLite" {
+        // the database is a local file
+        return connInfo.Database
+    }
+    return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+        connInfo.Username, connInfo.Password, connInfo.Host, connInfo.Port, connInfo.Database)
+}
+
+// quoteIdent quotes an identifier, so names that are reserved words or
+// mixed case can be used
+func (d *GoDialect) quoteIdent(name string) string {
+    if d.DBMS == "MySQL" {
+        return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+    }
+    return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+// tableRef is how queries refer to a table. PostgreSQL tables are schema
+// qualified, so queries don't depend on search_path. MySQL schemas are
+// databases, only qualified when not the one connected to, and SQLite ones
+// are the database file itself.
+func (d *GoDialect) tableRef(table *metadata.Table) string {
+    qualify := d.isPostgres()
+    if d.DBMS == "MySQL" && sourceConfig != nil {
+        qualify = table.Schema != "" && table.Schema != sourceConfig.ConnInfo.Database
+    }
+    if qualify && table.Schema != "" {
+        return d.quoteIdent(table.Schema) + "." + d.quoteIdent(table.Name)
+    }
+    return d.quoteIdent(table.Name)
+}
+
+// placeholder returns the n-th (1-based) bind parameter of a query
+func (d *GoDialect) placeholder(n int) string {
+    if d.isPostgres() {
+        return fmt.Sprintf("$%d", n)
+    }
+    return "?"
+}
+
+// goTypeOf maps a database type to its go counterpart, falling back to the
+// database type name when there is no known mapping. PostgreSQL types are
+// mapped by udt name, so display names are translated first.
+func (d *GoDialect) goTypeOf(datatype string) string {
+    if d.isPostgres() {
+        datatype = pgsql.UdtNameOf(datatype)
+    }
+    gotype, exists := d.GoTypes[datatype]
+    if !exists {
+        return datatype
+    }
+    return gotype
+}
+
+// typeNameOf is the database type a column is mapped by, that of its
+// elements for arrays
+func (d *GoDialect) typeNameOf(col *metadata.Column) string {
+    if d.isPostgres() {
+        return pgsql.ColumnUdtName(col)
+    }
+    if col.IsArray() && col.ElementType != "" {
+        return col.ElementType
+    }
+    return col.Datatype
+}
+
+// goTypeOfColumn maps a column to its go type, enum columns getting the
+// type generated for their enum and array columns a slice of their elements
+func (d *GoDialect) goTypeOfColumn(meta *metadata.Metadata, col *metadata.Column) string {
+    isArray := col.IsArray() && col.ElementType != ""
+    gotype := d.goTypeOf(d.typeNameOf(col))
+    if meta != nil {
+        if enum := meta.ColumnEnum(col); enum != nil {
+            gotype = meta.EnumTypeName(enum)
+        }
+    }
+    if isArray {
+        gotype = strings.Repeat("[]", max(col.ArrayDims, 1)) + gotype
+    }
+    return gotype
+}
+
+// hasEquality tells whether values of a column can be looked up with =
+func (d *GoDialect) hasEquality(col *metadata.Column) bool {
+    if d.isPostgres() {
+        if pgType := pgsql.SearchPgType(pgsql.ColumnUdtName(col)); pgType != nil {
+            return !pgType.NoEquality
+        }
+    }
+    return true
+}
+
+func goTypeOf(datatype string) string {
+    if override := goTypeOverride(datatype); override != nil {
+        return override.GoType
+    }
+    return dialect.goTypeOf(datatype)
+}
+
+func columnGoType(col *metadata.Column) string {
+    if override := goTypeOverride(dialect.typeNameOf(col)); override != nil {
+        if col.IsArray() && col.ElementType != "" {
+            return strings.Repeat("[]", max(col.ArrayDims, 1)) + override.GoType
+        }
+        return override.GoType
+    }
+    return dialect.goTypeOfColumn(sourceMetadata, col)
+}
+
+// GoFieldType returns the type of the struct field generated for a column,
+// overridden as the config tells
+func GoFieldType(dbms string, meta *metadata.Metadata, config *config.Config, table *metadata.Table, col *metadata.Column) (string, error) {
+    d, err := dialectFor(dbms)
+    if err != nil {
+        return "", err
+    }
+    previousDialect, previousMetadata, previousConfig := dialect, sourceMetadata, sourceConfig
+    dialect, sourceMetadata, sourceConfig = d, meta, config
+    defer func() {
+        dialect, sourceMetadata, sourceConfig = previousDialect, previousMetadata, previousConfig
+    }()
+
+    gotype := fieldGoType(table, col)
+    if col.Nullable {
+        return "*" + gotype, nil
+    }
+    return gotype, nil
+}
+
+// KeyFuncNames returns the names of the functions generated for a table out
+// of its unique constraints, indexes and foreign keys, that is its finders,
+// upserts and relations
+func KeyFuncNames(dbms string, meta *metadata.Metadata, table *metadata.Table) ([]string, error) {
+    d, err := dialectFor(dbms)
+    if err != nil {
+        return nil, err
+    }
+    previousDialect, previousMetadata := dialect, sourceMetadata
+    dialect, sourceMetadata = d, meta
+    defer func() {
+        dialect, sourceMetadata = previousDialect, previousMetadata
+    }()
+
+    source := GoSourceFile{}
+    err = generateFinders(table, &source)
+    if err != nil {
+        return nil, err
+    }
+    err = generateUpsert(table, &source)
+    if err != nil {
+        return nil, err
+    }
+    err = generateRelations(table, &source)
+    if err != nil {
+        return nil, err
+    }
+    names := make([]string, 0)
+    for i := range source.Funcs {
+        names = append(names, source.Funcs[i].Name)
+    }
+    return names, nil
+}
+
+// dbtxDecl declares the DBTX interface the generated functions run queries
+// through, which connections, pools and transactions all implement. pgx ones
+// also bulk load rows with the COPY protocol and send batches of queries.
+func (d *GoDialect) dbtxDecl() string {
+    decl := "// DBTX is implemented by connections, pools and transactions alike\n" +
+        "type DBTX interface {\n" +
+        "    " + d.ExecFunc + "(ctx context.Context, query string, args ...any) (" + d.ResultType + ", error)\n" +
+        "    " + d.QueryFunc + "(ctx context.Context, query string, args ...any) (" + d.RowsType + ", error)\n" +
+        "    " + d.QueryRowFunc + "(ctx context.Context, query string, args ...any) " + d.RowType + "\n"
+    if d.isPostgres() {
+        decl += "    CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)\n" +
+            "    SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults\n"
+    }
+    return decl + "}"
+}
+
+func ctxArg() GoFuncArg {
+    return GoFuncArg{Name: "ctx", Type: "context.Context", IsPointer: false}
+}
+
+func connArg() GoFuncArg {
+    return GoFuncArg{Name: "conn", Type: "DBTX", IsPointer: false}
+}
+
+// baseName is the name the files and types generated for a table derive from
+func baseName(table *metadata.Table) string {
+    if sourceMetadata == nil {
+        return table.Name
+    }
+    return sourceMetadata.TableBaseName(table)
+}
+
+// SQL is written in generated code either in double quoted strings, where
+// quotes are escaped, or in raw strings, which are closed around backticks
+
+func quotedSQL(sql string) string {
+    return strings.ReplaceAll(sql, "\"", "\\\"")
+}
+
+func rawSQL(sql string) string {
+    if !strings.Contains(sql, "`") {
+        return sql
+    }
+    return "` + \"" + quotedSQL(sql) + "\" + `"
+}
+
+// quotedIdent is a quoted identifier written in a double quoted string
+func quotedIdent(name string) string {
+    return quotedSQL(dialect.quoteIdent(name))
+}
+
+// rawIdent is a quoted identifier written in a raw string
+func rawIdent(name string) string {
+    return rawSQL(dialect.quoteIdent(name))
+}
+
+// quotedTable is the reference to a table written in a double quoted string
+func quotedTable(table *metadata.Table) string {
+    return quotedSQL(dialect.tableRef(table))
+}
+
+// rawTable is the reference to a table written in a raw string
+func rawTable(table *metadata.Table) string {
+    return rawSQL(dialect.tableRef(table))
+}
@@ -0,0 +1,157 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+    "fmt"
+    "strings"
+    "unicode"
+)
+
+// ======================================================================================
+//     Enum Generation
+// ======================================================================================
+
+// labelIdentifier turns an enum label into the suffix of its go constant,
+// as in "in-progress" -> "InProgress"
+func labelIdentifier(label string) string {
+    parts := strings.FieldsFunc(label, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    name := ""
+    for i := range parts {
+        runes := []rune(parts[i])
+        name += strings.ToUpper(string(runes[0])) + string(runes[1:])
+    }
+    return name
+}
+
+// enumConstNames returns the go constant of each label of an enum
+func enumConstNames(meta *metadata.Metadata, enum *metadata.Enum) []string {
+    typeName := meta.EnumTypeName(enum)
+    names := make([]string, len(enum.Labels))
+    for i := range enum.Labels {
+        name := typeName + labelIdentifier(enum.Labels[i])
+        if name == typeName || metadata.ContainsString(names[:i], name) {
+            // labels made only of symbols, or colliding once cleaned up
+            name += fmt.Sprintf("%d", i+1)
+        }
+        names[i] = name
+    }
+    return names
+}
+
+func generateEnum(meta *metadata.Metadata, enum *metadata.Enum, source *GoSourceFile) {
+    typeName := meta.EnumTypeName(enum)
+    constNames := enumConstNames(meta, enum)
+
+    source.addDecl(fmt.Sprintf("// %s is the %s.%s enum type\ntype %s string", typeName, enum.Schema, enum.Name, typeName))
+
+    consts := "const (\n"
+    for i := range enum.Labels {
+        consts += fmt.Sprintf("    %s %s = %q\n", constNames[i], typeName, enum.Labels[i])
+    }
+    consts += ")"
+    source.addDecl(consts)
+
+    receiver := "e"
+
+    isValidFunc := GoFuncs{
+        Name:     "IsValid",
+        Receiver: &GoFuncArg{Name: receiver, Type: typeName, IsPointer: false},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    isValidFunc.addReturn(GoFuncReturn{Type: "bool", IsPointer: false})
+    isValidFunc.addLine("switch " + receiver + " {")
+    if len(constNames) > 0 {
+        isValidFunc.addLine("case " + strings.Join(constNames, ", ") + ":")
+        isValidFunc.addLine("    return true")
+    }
+    isValidFunc.addLine("}")
+    isValidFunc.addLine("return false")
+    source.addFunc(isValidFunc)
+
+    scanFunc := GoFuncs{
+        Name:     "Scan",
+        Receiver: &GoFuncArg{Name: receiver, Type: typeName, IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    scanFunc.addArg(GoFuncArg{Name: "src", Type: "any", IsPointer: false})
+    scanFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    scanFunc.addLine("switch value := src.(type) {")
+    scanFunc.addLine("case string:")
+    scanFunc.addLine("    *" + receiver + " = " + typeName + "(value)")
+    scanFunc.addLine("case []byte:")
+    scanFunc.addLine("    *" + receiver + " = " + typeName + "(value)")
+    scanFunc.addLine("default:")
+    scanFunc.addLine("    return fmt.Errorf(\"cannot scan %T into " + typeName + "\", src)")
+    scanFunc.addLine("}")
+    scanFunc.addLine("if !" + receiver + ".IsValid() {")
+    scanFunc.addLine("    return fmt.Errorf(\"invalid " + typeName + " value: %q\", string(*" + receiver + "))")
+    scanFunc.addLine("}")
+    scanFunc.addLine("return nil")
+    source.addFunc(scanFunc)
+
+    valueFunc := GoFuncs{
+        Name:     "Value",
+        Receiver: &GoFuncArg{Name: receiver, Type: typeName, IsPointer: false},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    valueFunc.addReturn(GoFuncReturn{Type: "driver.Value", IsPointer: false})
+    valueFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    valueFunc.addLine("if !" + receiver + ".IsValid() {")
+    valueFunc.addLine("    return nil, fmt.Errorf(\"invalid " + typeName + " value: %q\", string(" + receiver + "))")
+    valueFunc.addLine("}")
+    valueFunc.addLine("return string(" + receiver + "), nil")
+    source.addFunc(valueFunc)
+}
+
+// enumArrayTypeNames lists the enums used by array columns, each followed by
+// its array type, in the order they have to be registered with pgx
+func enumArrayTypeNames(meta *metadata.Metadata) []string {
+    typeNames := make([]string, 0)
+    if meta == nil {
+        return typeNames
+    }
+    for i := range meta.Tables {
+        for j := range meta.Tables[i].Columns {
+            col := &meta.Tables[i].Columns[j]
+            enum := meta.ColumnEnum(col)
+            if enum == nil || !col.IsArray() {
+                continue
+            }
+            typeName := enum.Schema + "." + enum.Name
+            if !metadata.ContainsString(typeNames, typeName) {
+                typeNames = append(typeNames, typeName, col.UdtSchema+"."+col.UdtName)
+            }
+        }
+    }
+    return typeNames
+}
+
+// generateGoEnums writes the go types of all enums into enums.go
+func generateGoEnums(folder string, packageName string, meta *metadata.Metadata) error {
+    if len(meta.Enums) == 0 {
+        return nil
+    }
+    fmt.Printf("Generating enums file\n")
+
+    source := GoSourceFile{
+        Name:    "enums",
+        Package: packageName,
+        Imports: []string{"database/sql/driver", "fmt"},
+        Decls:   make([]string, 0),
+        Structs: make([]GoStruct, 0),
+        Funcs:   make([]GoFuncs, 0),
+    }
+    for i := range meta.Enums {
+        generateEnum(meta, &meta.Enums[i], &source)
+    }
+
+    return writeGoSource(folder, source)
+}
@@ -0,0 +1,209 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+)
+
+// ======================================================================================
+//     Filters
+// ======================================================================================
+
+// generateFilterTypes adds the Predicate and OrderBy types the SelectX
+// functions take, and the whereClause they build their queries with
+func generateFilterTypes(source *GoSourceFile) {
+    if !metadata.ContainsString(source.Imports, "strings") {
+        source.addImport("strings")
+    }
+
+    source.addDecl("// Predicate filters the rows of a select on a column, nil fields being\n" +
+        "// ignored and set ones combined with AND. Like applies to text columns and\n" +
+        "// IsNull, which selects NULL or NOT NULL values, to nullable ones.\n" +
+        "type Predicate[T any] struct {\n" +
+        "    Eq     *T\n" +
+        "    Neq    *T\n" +
+        "    In     []T\n" +
+        "    Like   *string\n" +
+        "    Gt     *T\n" +
+        "    Gte    *T\n" +
+        "    Lt     *T\n" +
+        "    Lte    *T\n" +
+        "    IsNull *bool\n" +
+        "}")
+    source.addDecl("// OrderBy orders the rows of a select by a column\n" +
+        "type OrderBy[C ~string] struct {\n" +
+        "    Column C\n" +
+        "    Desc   bool\n" +
+        "}")
+    source.addDecl("// whereClause accumulates the predicates of a select and their arguments\n" +
+        "type whereClause struct {\n" +
+        "    terms []string\n" +
+        "    args  []any\n" +
+        "}")
+
+    receiver := "w"
+
+    bindFunc := GoFuncs{
+        Name:     "bind",
+        Receiver: &GoFuncArg{Name: receiver, Type: "whereClause", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    bindFunc.addArg(GoFuncArg{Name: "arg", Type: "any", IsPointer: false})
+    bindFunc.addReturn(GoFuncReturn{Type: "string", IsPointer: false})
+    bindFunc.addLine(receiver + ".args = append(" + receiver + ".args, arg)")
+    if dialect.isPostgres() {
+        bindFunc.addLine("return fmt.Sprintf(\"$%d\", len(" + receiver + ".args))")
+    } else {
+        bindFunc.addLine("return \"?\"")
+    }
+    source.addFunc(bindFunc)
+
+    stringFunc := GoFuncs{
+        Name:     "String",
+        Receiver: &GoFuncArg{Name: receiver, Type: "whereClause", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    stringFunc.addReturn(GoFuncReturn{Type: "string", IsPointer: false})
+    stringFunc.addLine("if len(" + receiver + ".terms) == 0 {")
+    stringFunc.addLine("    return \"\"")
+    stringFunc.addLine("}")
+    stringFunc.addLine("return \" WHERE \" + strings.Join(" + receiver + ".terms, \" AND \")")
+    source.addFunc(stringFunc)
+
+    addFunc := GoFuncs{
+        Name:    "addPredicate[T any]",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    addFunc.addArg(GoFuncArg{Name: "w", Type: "whereClause", IsPointer: true})
+    addFunc.addArg(GoFuncArg{Name: "column", Type: "string", IsPointer: false})
+    addFunc.addArg(GoFuncArg{Name: "p", Type: "Predicate[T]", IsPointer: true})
+    addFunc.addLine("if p == nil {")
+    addFunc.addLine("    return")
+    addFunc.addLine("}")
+    for _, op := range [][]string{{"Eq", "="}, {"Neq", "<>"}, {"Like", "LIKE"}, {"Gt", ">"}, {"Gte", ">="}, {"Lt", "<"}, {"Lte", "<="}} {
+        addFunc.addLine("if p." + op[0] + " != nil {")
+        addFunc.addLine("    w.terms = append(w.terms, column+\" " + op[1] + " \"+w.bind(*p." + op[0] + "))")
+        addFunc.addLine("}")
+    }
+    // placeholders are expanded, as not every DBMS binds arrays
+    addFunc.addLine("if p.In != nil {")
+    addFunc.addLine("    placeholders := make([]string, 0, len(p.In))")
+    addFunc.addLine("    for i := range p.In {")
+    addFunc.addLine("        placeholders = append(placeholders, w.bind(p.In[i]))")
+    addFunc.addLine("    }")
+    addFunc.addLine("    if len(placeholders) == 0 {")
+    addFunc.addLine("        w.terms = append(w.terms, \"1 = 0\")")
+    addFunc.addLine("    } else {")
+    addFunc.addLine("        w.terms = append(w.terms, column+\" IN (\"+strings.Join(placeholders, \", \")+\")\")")
+    addFunc.addLine("    }")
+    addFunc.addLine("}")
+    addFunc.addLine("if p.IsNull != nil && *p.IsNull {")
+    addFunc.addLine("    w.terms = append(w.terms, column+\" IS NULL\")")
+    addFunc.addLine("} else if p.IsNull != nil {")
+    addFunc.addLine("    w.terms = append(w.terms, column+\" IS NOT NULL\")")
+    addFunc.addLine("}")
+    source.addFunc(addFunc)
+}
+
+// filterColumns are the columns a table can be filtered on, json documents
+// and types without equality being left out
+func filterColumns(table *metadata.Table) []*metadata.Column {
+    cols := make([]*metadata.Column, 0)
+    for i := range table.Columns {
+        col := &table.Columns[i]
+        if isJSONColumn(col) || !dialect.hasEquality(col) {
+            continue
+        }
+        cols = append(cols, col)
+    }
+    return cols
+}
+
+// generateFilter adds the XColumn names, the XFilter struct and SelectX,
+// which selects the rows matching a filter in the given order. A zero limit
+// selects every row.
+func generateFilter(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    columnType := tableNamePascalCase + "Column"
+    filterType := tableNamePascalCase + "Filter"
+
+    // column names, so rows are ordered by known columns only
+    columnDecl := "// " + columnType + " names a column of " + table.Name + "\n" +
+        "type " + columnType + " string\n\n" +
+        "const (\n"
+    for i := range table.Columns {
+        name := columnType + metadata.ToPascalCase(table.Columns[i].Name)
+        columnDecl += "    " + name + " " + columnType + " = \"" + table.Columns[i].Name + "\"\n"
+    }
+    source.addDecl(columnDecl + ")")
+
+    cols := filterColumns(table)
+    filterDecl := "// " + filterType + " selects rows of " + table.Name + ", a row matching every predicate set\n" +
+        "type " + filterType + " struct {\n"
+    for i := range cols {
+        filterDecl += "    " + metadata.ToPascalCase(cols[i].Name) + " *Predicate[" + fieldGoType(table, cols[i]) + "]\n"
+        addFieldImports(table, cols[i], source)
+    }
+    source.addDecl(filterDecl + "}")
+
+    if !metadata.ContainsString(source.Imports, "strings") {
+        source.addImport("strings")
+    }
+
+    selectFunc := GoFuncs{
+        Name:    "Select" + tableNamePascalCase,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    selectFunc.addArg(ctxArg())
+    selectFunc.addArg(connArg())
+    selectFunc.addArg(GoFuncArg{Name: "filter", Type: filterType, IsPointer: false})
+    selectFunc.addArg(GoFuncArg{Name: "order", Type: "[]OrderBy[" + columnType + "]", IsPointer: false})
+    selectFunc.addArg(GoFuncArg{Name: "limit", Type: "uint", IsPointer: false})
+    selectFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
+    selectFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    selectFunc.addLine("var where whereClause")
+    for i := range cols {
+        selectFunc.addLine("addPredicate(&where, \"" + quotedIdent(cols[i].Name) + "\", filter." + metadata.ToPascalCase(cols[i].Name) + ")")
+    }
+    selectFunc.addLine("query := \"" + selectFrom(table) + "\" + where.String()")
+    selectFunc.addLine("")
+    selectFunc.addLine("terms := make([]string, 0, len(order))")
+    selectFunc.addLine("for i := range order {")
+    selectFunc.addLine("    var term string")
+    selectFunc.addLine("    switch order[i].Column {")
+    for i := range table.Columns {
+        selectFunc.addLine("    case " + columnType + metadata.ToPascalCase(table.Columns[i].Name) + ":")
+        selectFunc.addLine("        term = \"" + quotedIdent(table.Columns[i].Name) + "\"")
+    }
+    selectFunc.addLine("    default:")
+    selectFunc.addLine("        return nil, fmt.Errorf(\"unknown column %q\", order[i].Column)")
+    selectFunc.addLine("    }")
+    selectFunc.addLine("    if order[i].Desc {")
+    selectFunc.addLine("        term += \" DESC\"")
+    selectFunc.addLine("    }")
+    selectFunc.addLine("    terms = append(terms, term)")
+    selectFunc.addLine("}")
+    selectFunc.addLine("if len(terms) > 0 {")
+    selectFunc.addLine("    query += \" ORDER BY \" + strings.Join(terms, \", \")")
+    selectFunc.addLine("}")
+    selectFunc.addLine("if limit > 0 {")
+    selectFunc.addLine("    query += \" LIMIT \" + where.bind(limit)")
+    selectFunc.addLine("}")
+    selectFunc.addLine("")
+    selectFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, query, where.args...)")
+    addIfErr(&selectFunc, "error selecting rows: %w", 0)
+    selectFunc.addLine("defer rows.Close()")
+    selectFunc.addLine("")
+    selectFunc.addLine("return ScanAll" + tableNamePascalCase + "Rows(&rows)")
+
+    source.addFunc(selectFunc)
+    return nil
+}
@@ -0,0 +1,140 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+    "fmt"
+    "strings"
+)
+
+// ======================================================================================
+//     Finders
+// ======================================================================================
+
+// finderKey is a list of columns rows are looked up by, unique ones
+// selecting a single row
+type finderKey struct {
+    cols   []*metadata.Column
+    unique bool
+}
+
+// finderKeys returns the column lists the indexes of a table serve lookups
+// by, that is the columns of each index and their leading prefixes, along
+// with the columns of the unique constraints. A column that can't be looked
+// up by equality ends a key, and the primary key is left to SelectXByPK.
+func finderKeys(table *metadata.Table) ([]finderKey, error) {
+    keys := make([]finderKey, 0)
+    seen := make(map[string]int)
+    addKey := func(columnNames []string, unique bool, source string) error {
+        cols := make([]*metadata.Column, 0)
+        for i := range columnNames {
+            col := table.SearchColumnByName(columnNames[i])
+            if col == nil {
+                return fmt.Errorf("column %s of %s not found in table %s", columnNames[i], source, table.Name)
+            }
+            if isJSONColumn(col) || !dialect.hasEquality(col) {
+                return nil
+            }
+            cols = append(cols, col)
+        }
+        if isPrimaryKey(table, cols) {
+            return nil
+        }
+        name := strings.Join(columnNames, ",")
+        if i, exists := seen[name]; exists {
+            keys[i].unique = keys[i].unique || unique
+            return nil
+        }
+        seen[name] = len(keys)
+        keys = append(keys, finderKey{cols: cols, unique: unique})
+        return nil
+    }
+
+    for _, index := range table.Indexes {
+        for n := 1; n <= len(index.Columns); n++ {
+            err := addKey(index.Columns[:n], index.IsUnique && n == len(index.Columns), "index "+index.Name)
+            if err != nil {
+                return nil, err
+            }
+        }
+    }
+    for _, constraint := range table.SearchConstraints(metadata.UniqueConstraint) {
+        err := addKey(constraint.Columns, true, "constraint "+constraint.Name)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return keys, nil
+}
+
+// isPrimaryKey reports whether columns are those of the primary key, in any order
+func isPrimaryKey(table *metadata.Table, cols []*metadata.Column) bool {
+    pks := primaryKeyColumns(table)
+    if len(pks) != len(cols) {
+        return false
+    }
+    for i := range cols {
+        if !cols[i].IsPrimaryKey {
+            return false
+        }
+    }
+    return true
+}
+
+// generateFinders adds, for every finder key, SelectXByYAndZ which looks up
+// the single row holding the given values of a unique key, or SelectAllXByY
+// which selects the rows holding the given values of the leading columns of
+// an index. NULL values are never equal, so nil arguments find no row.
+func generateFinders(table *metadata.Table, source *GoSourceFile) error {
+    keys, err := finderKeys(table)
+    if err != nil {
+        return err
+    }
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+
+    for _, key := range keys {
+        columnNames := make([]string, 0)
+        conditions := make([]string, 0)
+        args := make([]string, 0)
+        for i := range key.cols {
+            columnNames = append(columnNames, metadata.ToPascalCase(key.cols[i].Name))
+            conditions = append(conditions, quotedIdent(key.cols[i].Name)+" = "+dialect.placeholder(i+1))
+            args = append(args, columnArgName(key.cols[i]))
+        }
+
+        name := "SelectAll" + tableNamePascalCase + "By" + strings.Join(columnNames, "And")
+        if key.unique {
+            name = "Select" + tableNamePascalCase + "By" + strings.Join(columnNames, "And")
+        }
+        findFunc := GoFuncs{
+            Name:    name,
+            Args:    make([]GoFuncArg, 0),
+            Returns: make([]GoFuncReturn, 0),
+            Lines:   make([]string, 0),
+        }
+        findFunc.addArg(ctxArg())
+        findFunc.addArg(connArg())
+        for i := range key.cols {
+            findFunc.addArg(GoFuncArg{Name: args[i], Type: fieldGoType(table, key.cols[i]), IsPointer: false})
+            addFieldImports(table, key.cols[i], source)
+        }
+
+        sql := selectFrom(table) + " WHERE " + strings.Join(conditions, " AND ")
+        if key.unique {
+            findFunc.addReturn(GoFuncReturn{Type: tableNamePascalCase, IsPointer: true})
+            findFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+            findFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, \"" + sql + "\", " + strings.Join(args, ", ") + ")")
+            findFunc.addLine("return ScanSingle" + tableNamePascalCase + "Row(&row)")
+        } else {
+            findFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
+            findFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+            findFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, \"" + sql + "\", " + strings.Join(args, ", ") + ")")
+            addIfErr(&findFunc, "error selecting rows: %w", 0)
+            findFunc.addLine("defer rows.Close()")
+            findFunc.addLine("")
+            findFunc.addLine("return ScanAll" + tableNamePascalCase + "Rows(&rows)")
+        }
+
+        source.addFunc(findFunc)
+    }
+    return nil
+}
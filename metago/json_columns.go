@@ -0,0 +1,117 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+)
+
+// ======================================================================================
+//     JSON Columns
+// ======================================================================================
+
+// isJSONColumn tells whether a column holds json documents, which can't be
+// compared as plain values
+func isJSONColumn(col *metadata.Column) bool {
+    return col.Datatype == "json" || col.Datatype == "jsonb"
+}
+
+// isBoundJSONColumn tells whether the documents of a json column are
+// marshalled from and unmarshalled into its field through a jsonColumn: those
+// of columns overridden by a go type, and on SQLite, whose driver reads json
+// text into strings a json.RawMessage can't be scanned from, all of them
+func isBoundJSONColumn(table *metadata.Table, col *metadata.Column) bool {
+    if !isJSONColumn(col) {
+        return false
+    }
+    return dialect.DBMS == "SQLite" || isOverridden(table, col)
+}
+
+// scanTarget is the destination handed to Scan for the field of a column,
+// bound json columns being unmarshalled through a jsonColumn
+func scanTarget(table *metadata.Table, col *metadata.Column, field string) string {
+    if isBoundJSONColumn(table, col) {
+        return "&jsonColumn{&" + field + "}"
+    }
+    return "&" + field
+}
+
+// bindValue is the query argument carrying the field of a column, bound json
+// columns being marshalled through a jsonColumn
+func bindValue(table *metadata.Table, col *metadata.Column, field string) string {
+    if isBoundJSONColumn(table, col) {
+        return "jsonColumn{&" + field + "}"
+    }
+    return field
+}
+
+// hasJSONBindings tells whether any column of the metadata goes through a jsonColumn
+func hasJSONBindings(meta *metadata.Metadata) bool {
+    for i := range meta.Tables {
+        for j := range meta.Tables[i].Columns {
+            if isBoundJSONColumn(&meta.Tables[i], &meta.Tables[i].Columns[j]) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// generateJSONColumnType adds the jsonColumn type, which adapts the field of a
+// bound json column to sql.Scanner and driver.Valuer
+func generateJSONColumnType(source *GoSourceFile) {
+    for _, i := range []string{"database/sql/driver", "encoding/json", "reflect"} {
+        if !metadata.ContainsString(source.Imports, i) {
+            source.addImport(i)
+        }
+    }
+
+    source.addDecl("// jsonColumn marshals and unmarshals a json column through the go type of its field\n" +
+        "type jsonColumn struct {\n" +
+        "    target any\n" +
+        "}")
+
+    receiver := "c"
+
+    scanFunc := GoFuncs{
+        Name:     "Scan",
+        Receiver: &GoFuncArg{Name: receiver, Type: "jsonColumn", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    scanFunc.addArg(GoFuncArg{Name: "src", Type: "any", IsPointer: false})
+    scanFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    scanFunc.addLine("switch value := src.(type) {")
+    scanFunc.addLine("case nil:")
+    scanFunc.addLine("    return nil")
+    scanFunc.addLine("case string:")
+    scanFunc.addLine("    return json.Unmarshal([]byte(value), " + receiver + ".target)")
+    scanFunc.addLine("case []byte:")
+    scanFunc.addLine("    return json.Unmarshal(value, " + receiver + ".target)")
+    scanFunc.addLine("}")
+    scanFunc.addLine("return fmt.Errorf(\"cannot scan %T into a json column\", src)")
+    source.addFunc(scanFunc)
+
+    valueFunc := GoFuncs{
+        Name:     "Value",
+        Receiver: &GoFuncArg{Name: receiver, Type: "jsonColumn", IsPointer: false},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    valueFunc.addReturn(GoFuncReturn{Type: "driver.Value", IsPointer: false})
+    valueFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    // nil fields of nullable columns are stored as NULL rather than as a json null
+    valueFunc.addLine("value := reflect.ValueOf(" + receiver + ".target)")
+    valueFunc.addLine("for value.Kind() == reflect.Pointer {")
+    valueFunc.addLine("    if value.IsNil() {")
+    valueFunc.addLine("        return nil, nil")
+    valueFunc.addLine("    }")
+    valueFunc.addLine("    value = value.Elem()")
+    valueFunc.addLine("}")
+    valueFunc.addLine("data, err := json.Marshal(value.Interface())")
+    valueFunc.addLine("if err != nil {")
+    valueFunc.addLine("    return nil, err")
+    valueFunc.addLine("}")
+    valueFunc.addLine("return string(data), nil")
+    source.addFunc(valueFunc)
+}
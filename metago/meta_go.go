@@ -3,7 +3,6 @@ package metago
 import (
     "dto-gen/config"
     "dto-gen/metadata"
-    "dto-gen/pgsql"
     "fmt"
     "os"
     "os/exec"
@@ -19,6 +18,7 @@ type GoSourceFile struct {
     Name    string
     Package string
     Imports []string
+    Decls   []string
     Structs []GoStruct
     Funcs   []GoFuncs
 }
@@ -27,6 +27,11 @@ func (s *GoSourceFile) addImport(i string) {
     s.Imports = append(s.Imports, i)
 }
 
+// addDecl adds a type or const declaration, written as is
+func (s *GoSourceFile) addDecl(d string) {
+    s.Decls = append(s.Decls, d)
+}
+
 func (s *GoSourceFile) addStruct(st GoStruct) {
     s.Structs = append(s.Structs, st)
 }
@@ -37,6 +42,7 @@ func (s *GoSourceFile) addFunc(f GoFuncs) {
 
 type GoStruct struct {
     Name   string
+    Doc    string
     Fields []GoStructField
 }
 
@@ -49,6 +55,7 @@ type GoStructField struct {
     Type       string
     IsPointer  bool
     Annotation *GoStructFieldAnnotation
+    Doc        string
 }
 
 type GoStructFieldAnnotation struct {
@@ -57,10 +64,11 @@ type GoStructFieldAnnotation struct {
 }
 
 type GoFuncs struct {
-    Name    string
-    Args    []GoFuncArg
-    Returns []GoFuncReturn
-    Lines   []string
+    Name     string
+    Receiver *GoFuncArg
+    Args     []GoFuncArg
+    Returns  []GoFuncReturn
+    Lines    []string
 }
 
 func (f *GoFuncs) addArg(arg GoFuncArg) {
@@ -86,6 +94,20 @@ type GoFuncReturn struct {
     IsPointer bool
 }
 
+// docComment turns a text into comment lines at the given indentation
+func docComment(text string, indent string) string {
+    comment := ""
+    for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+        line = strings.TrimRight(line, " \t\r")
+        if line == "" {
+            comment += indent + "//\n"
+        } else {
+            comment += indent + "// " + line + "\n"
+        }
+    }
+    return comment
+}
+
 func writeGoSource(folder string, source GoSourceFile) error {
     text := ""
     // add package
@@ -95,17 +117,34 @@ func writeGoSource(folder string, source GoSourceFile) error {
     if len(source.Imports) > 0 {
         text += "import (\n"
         for i := range source.Imports {
-            text += "    \"" + source.Imports[i] + "\"\n"
+            // imports may carry an alias, as in "_ github.com/go-sql-driver/mysql"
+            parts := strings.SplitN(source.Imports[i], " ", 2)
+            if len(parts) == 2 {
+                text += "    " + parts[0] + " \"" + parts[1] + "\"\n"
+            } else {
+                text += "    \"" + source.Imports[i] + "\"\n"
+            }
         }
         text += ")\n\n"
     }
 
+    // add declarations
+    for i := range source.Decls {
+        text += source.Decls[i] + "\n\n"
+    }
+
     // add stucts
     for i := range source.Structs {
         currStruct := source.Structs[i]
+        if currStruct.Doc != "" {
+            text += docComment(currStruct.Doc, "")
+        }
         text += "type " + currStruct.Name + " struct {\n"
         for j := range currStruct.Fields {
             currField := currStruct.Fields[j]
+            if currField.Doc != "" {
+                text += docComment(currField.Doc, "    ")
+            }
             text += "    " + currField.Name + " "
             if currField.IsPointer {
                 text += "*"
@@ -122,7 +161,15 @@ func writeGoSource(folder string, source GoSourceFile) error {
     // add funcs
     for i := range source.Funcs {
         f := source.Funcs[i]
-        text += "func " + f.Name + "("
+        text += "func "
+        if f.Receiver != nil {
+            text += "(" + f.Receiver.Name + " "
+            if f.Receiver.IsPointer {
+                text += "*"
+            }
+            text += f.Receiver.Type + ") "
+        }
+        text += f.Name + "("
         for j := range f.Args {
             text += f.Args[j].Name + " "
             if f.Args[j].IsPointer {
@@ -226,14 +273,55 @@ func generateGoDbConnector(connInfo *config.ConnectionInfo, folder string, packa
     source := GoSourceFile{
         Name:    "db_connector",
         Package: packageName,
-        Imports: []string{"context", "github.com/jackc/pgx/v5", "fmt"},
+        Imports: append([]string{"context", "fmt"}, dialect.Imports...),
+        Decls:   make([]string, 0),
         Structs: make([]GoStruct, 0),
         Funcs:   make([]GoFuncs, 0),
     }
+    if dialect.ResultImport != "" {
+        source.addImport(dialect.ResultImport)
+    }
+    if dialect.PoolImport != "" {
+        source.addImport(dialect.PoolImport)
+    }
+    if dialect.DBMS == "MySQL" {
+        // named, as WithTx inspects its errors
+        source.addImport(dialect.Driver)
+    } else if dialect.Driver != "" {
+        source.addImport("_ " + dialect.Driver)
+    }
+
+    // interface the generated functions run their queries through
+    source.addDecl(dialect.dbtxDecl())
+
+    // pgx only knows how to handle arrays of enums once their types are loaded
+    typeNames := make([]string, 0)
+    if dialect.isPostgres() {
+        typeNames = enumArrayTypeNames(sourceMetadata)
+    }
+    if len(typeNames) > 0 {
+        registerFunc := GoFuncs{
+            Name:    "registerTypes",
+            Args:    make([]GoFuncArg, 0),
+            Returns: make([]GoFuncReturn, 0),
+            Lines:   make([]string, 0),
+        }
+        registerFunc.addArg(GoFuncArg{Name: "ctx", Type: "context.Context", IsPointer: false})
+        registerFunc.addArg(GoFuncArg{Name: "conn", Type: dialect.ConnType, IsPointer: true})
+        registerFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+        registerFunc.addLine("for _, typeName := range []string{\"" + strings.Join(typeNames, "\", \"") + "\"} {")
+        registerFunc.addLine("    dataType, err := conn.LoadType(ctx, typeName)")
+        registerFunc.addLine("    if err != nil {")
+        registerFunc.addLine("        return fmt.Errorf(\"error loading type %s: %w\", typeName, err)")
+        registerFunc.addLine("    }")
+        registerFunc.addLine("    conn.TypeMap().RegisterType(dataType)")
+        registerFunc.addLine("}")
+        registerFunc.addLine("return nil")
+        source.addFunc(registerFunc)
+    }
 
     // Func for connection
-    connString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
-        connInfo.Host, connInfo.Port, connInfo.Username, connInfo.Password, connInfo.Database)
+    connString := dialect.connectionString(connInfo)
 
     connectFunc := GoFuncs{
         Name:    "Connect",
@@ -241,16 +329,34 @@ func generateGoDbConnector(connInfo *config.ConnectionInfo, folder string, packa
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
+    connectFunc.addArg(ctxArg())
     connectFunc.addArg(GoFuncArg{Name: "connectionUrl", Type: "string", IsPointer: true})
-    connectFunc.addReturn(GoFuncReturn{Type: "pgx.Conn", IsPointer: true})
+    connectFunc.addReturn(GoFuncReturn{Type: dialect.ConnType, IsPointer: true})
     connectFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
     connectFunc.addLine("if connectionUrl == nil {")
     connectFunc.addLine("    defaultUrl := \"" + connString + "\"")
     connectFunc.addLine("    connectionUrl = &defaultUrl")
     connectFunc.addLine("}")
-    connectFunc.addLine("conn, err := pgx.Connect(context.Background(), *connectionUrl)")
-    addIfErr(&connectFunc, "error connecting to postgres: %w", 0)
+    if dialect.isPostgres() {
+        connectFunc.addLine("conn, err := pgx.Connect(ctx, *connectionUrl)")
+        addIfErr(&connectFunc, "error connecting to postgres: %w", 0)
+        if len(typeNames) > 0 {
+            connectFunc.addLine("err = registerTypes(ctx, conn)")
+            connectFunc.addLine("if err != nil {")
+            connectFunc.addLine("    conn.Close(ctx)")
+            connectFunc.addLine("    return nil, err")
+            connectFunc.addLine("}")
+        }
+    } else {
+        connectFunc.addLine("conn, err := sql.Open(\"" + dialect.DriverName + "\", *connectionUrl)")
+        addIfErr(&connectFunc, "error connecting to "+strings.ToLower(dialect.DBMS)+": %w", 0)
+        connectFunc.addLine("err = conn.PingContext(ctx)")
+        connectFunc.addLine("if err != nil {")
+        connectFunc.addLine("    conn.Close()")
+        connectFunc.addLine("    return nil, fmt.Errorf(\"error connecting to " + strings.ToLower(dialect.DBMS) + ": %w\", err)")
+        connectFunc.addLine("}")
+    }
     connectFunc.addLine("return conn, nil")
     source.addFunc(connectFunc)
 
@@ -261,10 +367,62 @@ func generateGoDbConnector(connInfo *config.ConnectionInfo, folder string, packa
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    disconnectFunc.addArg(GoFuncArg{Name: "connection", Type: "pgx.Conn", IsPointer: true})
-    disconnectFunc.addLine("connection.Close(context.Background())")
+    disconnectFunc.addArg(ctxArg())
+    disconnectFunc.addArg(GoFuncArg{Name: "connection", Type: dialect.ConnType, IsPointer: true})
+    if dialect.isPostgres() {
+        disconnectFunc.addLine("connection.Close(ctx)")
+    } else {
+        disconnectFunc.addLine("connection.Close()")
+    }
     source.addFunc(disconnectFunc)
 
+    // database/sql connections are pools already, pgx ones need pgxpool
+    if dialect.PoolType != "" {
+        connectPoolFunc := GoFuncs{
+            Name:    "ConnectPool",
+            Args:    make([]GoFuncArg, 0),
+            Returns: make([]GoFuncReturn, 0),
+            Lines:   make([]string, 0),
+        }
+        connectPoolFunc.addArg(ctxArg())
+    connectPoolFunc.addArg(GoFuncArg{Name: "connectionUrl", Type: "string", IsPointer: true})
+        connectPoolFunc.addReturn(GoFuncReturn{Type: dialect.PoolType, IsPointer: true})
+        connectPoolFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+        connectPoolFunc.addLine("if connectionUrl == nil {")
+        connectPoolFunc.addLine("    defaultUrl := \"" + connString + "\"")
+        connectPoolFunc.addLine("    connectionUrl = &defaultUrl")
+        connectPoolFunc.addLine("}")
+        connectPoolFunc.addLine("poolConfig, err := pgxpool.ParseConfig(*connectionUrl)")
+        addIfErr(&connectPoolFunc, "error parsing pool config: %w", 0)
+        if len(typeNames) > 0 {
+            connectPoolFunc.addLine("poolConfig.AfterConnect = registerTypes")
+        }
+        connectPoolFunc.addLine("pool, err := pgxpool.NewWithConfig(ctx, poolConfig)")
+        addIfErr(&connectPoolFunc, "error connecting to postgres: %w", 0)
+        connectPoolFunc.addLine("return pool, nil")
+        source.addFunc(connectPoolFunc)
+
+        disconnectPoolFunc := GoFuncs{
+            Name:    "DisconnectPool",
+            Args:    make([]GoFuncArg, 0),
+            Returns: make([]GoFuncReturn, 0),
+            Lines:   make([]string, 0),
+        }
+        disconnectPoolFunc.addArg(GoFuncArg{Name: "pool", Type: dialect.PoolType, IsPointer: true})
+        disconnectPoolFunc.addLine("pool.Close()")
+        source.addFunc(disconnectPoolFunc)
+    }
+
+    generateTxHelpers(&source)
+    generateSendBatch(&source)
+    generateCursorType(&source)
+    generateFilterTypes(&source)
+
+    if hasJSONBindings(sourceMetadata) {
+        generateJSONColumnType(&source)
+    }
+
     err := writeGoSource(folder, source)
     if err != nil {
         return err
@@ -273,39 +431,81 @@ func generateGoDbConnector(connInfo *config.ConnectionInfo, folder string, packa
     return nil
 }
 
+// columnListName names the constant listing the columns of a table
+func columnListName(table *metadata.Table) string {
+    return metadata.ToPascalCase(baseName(table)) + "Columns"
+}
+
+// selectFrom starts a query selecting the columns of a table, to be written
+// in a double quoted string
+func selectFrom(table *metadata.Table) string {
+    return "SELECT \" + " + columnListName(table) + " + \" FROM " + quotedTable(table)
+}
+
+// generateColumnList adds the constant listing the columns of a table, in the
+// order the scan functions scan them, so queries never depend on the order of
+// the columns in the database. Columns are qualified the way queries refer to
+// the table, schema included where it is.
+func generateColumnList(table *metadata.Table, source *GoSourceFile) {
+    columnNames := make([]string, 0)
+    for i := range table.Columns {
+        columnNames = append(columnNames, quotedTable(table)+"."+quotedIdent(table.Columns[i].Name))
+    }
+    source.addDecl("// " + columnListName(table) + " lists the columns of " + table.Name + " in the order its rows are scanned\n" +
+        "const " + columnListName(table) + " = \"" + strings.Join(columnNames, ", ") + "\"")
+}
+
 func generateTableStruct(table *metadata.Table, source *GoSourceFile) error {
+    // comments on the table and its columns document the struct and its fields
     entity := GoStruct{
-        Name:   metadata.ToPascalCase(table.Name),
+        Name:   metadata.ToPascalCase(baseName(table)),
+        Doc:    table.Comment,
         Fields: make([]GoStructField, 0),
     }
     for i := range table.Columns {
-        datatype := table.Columns[i].Datatype
-        gotype, exists := pgsql.PostgreSQLToGolangTypes[datatype]
-        if !exists {
-            gotype = datatype
-        }
-        if strings.HasPrefix(gotype, "time.") && !metadata.ContainsString(source.Imports, "time") {
-            source.addImport("time")
-        }
+        addFieldImports(table, &table.Columns[i], source)
 
         entity.addField(GoStructField{
             Name:      metadata.ToPascalCase(table.Columns[i].Name),
-            Type:      gotype,
+            Type:      fieldGoType(table, &table.Columns[i]),
             IsPointer: table.Columns[i].Nullable,
             Annotation: &GoStructFieldAnnotation{
                 Name:  "json",
                 Value: table.Columns[i].Name,
             },
+            Doc: table.Columns[i].Comment,
         })
     }
     source.addStruct(entity)
+    generateColumnList(table, source)
 
     return nil
 }
 
+// toStringType is the go type driving the conversion of a field in the
+// ToString functions, "enum" and "array" standing for any generated enum
+// type and any slice of array elements, "override" for any go type set by
+// the config and "pgtype" for any pgx type
+func toStringType(table *metadata.Table, col *metadata.Column) string {
+    if isOverridden(table, col) {
+        return "override"
+    }
+    if col.IsArray() && col.ElementType != "" {
+        return "array"
+    }
+    if sourceMetadata != nil && sourceMetadata.ColumnEnum(col) != nil {
+        return "enum"
+    }
+    gotype := columnGoType(col)
+    if strings.HasPrefix(gotype, "pgtype.") {
+        return "pgtype"
+    }
+    return gotype
+}
+
 func generateToString(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
 
     // function that converts the struct to string
     toStringFunc := GoFuncs{
@@ -322,7 +522,7 @@ func generateToString(table *metadata.Table, source *GoSourceFile) error {
     for i := range table.Columns {
         col := table.Columns[i]
         conversionStr := ""
-        switch pgsql.PostgreSQLToGolangTypes[col.Datatype] {
+        switch toStringType(table, &col) {
         case "[]byte":
         case "rune":
             conversionStr += "fmt.Sprintf(\"%c\", " +
@@ -334,11 +534,7 @@ func generateToString(table *metadata.Table, source *GoSourceFile) error {
             conversionStr += "fmt.Sprintf(\"%f\", " +
                 fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name)) +
                 ")"
-        case "int":
-            fallthrough
-        case "int16":
-            fallthrough
-        case "int64":
+        case "int", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
             conversionStr += "fmt.Sprintf(\"%d\", " +
                 fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name)) +
                 ")"
@@ -354,6 +550,26 @@ func generateToString(table *metadata.Table, source *GoSourceFile) error {
             }
         case "time.Time":
             conversionStr += fmt.Sprintf("%s.%s.Format(\"2006-01-02 15:04\")", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+        case "enum":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("string(*%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("string(%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
+        case "json.RawMessage":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("string(*%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("string(%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
+        case "time.Duration", "netip.Prefix", "net.HardwareAddr":
+            conversionStr += fmt.Sprintf("%s.%s.String()", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+        case "array", "override", "pgtype":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("fmt.Sprintf(\"%%v\", *%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("fmt.Sprintf(\"%%v\", %s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
         default:
             conversionStr += fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name))
         }
@@ -379,8 +595,8 @@ func generateToString(table *metadata.Table, source *GoSourceFile) error {
 }
 
 func generateMultiLineToString(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
 
     // function that converts the struct to string
     toStringFunc := GoFuncs{
@@ -398,7 +614,7 @@ func generateMultiLineToString(table *metadata.Table, source *GoSourceFile) erro
     for i := range table.Columns {
         col := table.Columns[i]
         conversionStr := ""
-        switch pgsql.PostgreSQLToGolangTypes[col.Datatype] {
+        switch toStringType(table, &col) {
         case "[]byte":
         case "rune":
             conversionStr += "fmt.Sprintf(\"%c\", " +
@@ -410,11 +626,7 @@ func generateMultiLineToString(table *metadata.Table, source *GoSourceFile) erro
             conversionStr += "fmt.Sprintf(\"%f\", " +
                 fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name)) +
                 ")"
-        case "int":
-            fallthrough
-        case "int16":
-            fallthrough
-        case "int64":
+        case "int", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
             conversionStr += "fmt.Sprintf(\"%d\", " +
                 fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name)) +
                 ")"
@@ -430,6 +642,26 @@ func generateMultiLineToString(table *metadata.Table, source *GoSourceFile) erro
             }
         case "time.Time":
             conversionStr += fmt.Sprintf("%s.%s.Format(\"2006-01-02 15:04\")", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+        case "enum":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("string(*%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("string(%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
+        case "json.RawMessage":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("string(*%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("string(%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
+        case "time.Duration", "netip.Prefix", "net.HardwareAddr":
+            conversionStr += fmt.Sprintf("%s.%s.String()", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+        case "array", "override", "pgtype":
+            if col.Nullable {
+                conversionStr += fmt.Sprintf("fmt.Sprintf(\"%%v\", *%s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            } else {
+                conversionStr += fmt.Sprintf("fmt.Sprintf(\"%%v\", %s.%s)", tableNameCamelCase, metadata.ToPascalCase(col.Name))
+            }
         default:
             conversionStr += fmt.Sprintf("%s.%s", tableNameCamelCase, metadata.ToPascalCase(col.Name))
         }
@@ -455,17 +687,17 @@ func generateMultiLineToString(table *metadata.Table, source *GoSourceFile) erro
 }
 
 func generateScanRow(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
 
-    // function that receives *pgx.Rows and scan one row
+    // function that receives the query rows and scan one row
     scanRowFunc := GoFuncs{
         Name:    "Scan" + tableNamePascalCase + "Row",
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    scanRowFunc.addArg(GoFuncArg{Name: "rows", Type: "pgx.Rows", IsPointer: true})
+    scanRowFunc.addArg(GoFuncArg{Name: "rows", Type: dialect.RowsType, IsPointer: true})
     scanRowFunc.addReturn(GoFuncReturn{Type: tableNamePascalCase, IsPointer: true})
     scanRowFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
@@ -474,10 +706,11 @@ func generateScanRow(table *metadata.Table, source *GoSourceFile) error {
 
     for i := range source.Structs[0].Fields {
         sf := source.Structs[0].Fields[i]
+        target := scanTarget(table, &table.Columns[i], tableNameCamelCase+"."+sf.Name)
         if i == len(source.Structs[0].Fields)-1 {
-            scanRowFunc.addLine("    &" + tableNameCamelCase + "." + sf.Name + ")")
+            scanRowFunc.addLine("    " + target + ")")
         } else {
-            scanRowFunc.addLine("    &" + tableNameCamelCase + "." + sf.Name + ",")
+            scanRowFunc.addLine("    " + target + ",")
         }
     }
 
@@ -486,14 +719,14 @@ func generateScanRow(table *metadata.Table, source *GoSourceFile) error {
 
     source.addFunc(scanRowFunc)
 
-    // function that receives *pgx.Row and scan one row
+    // function that receives a single query row and scan it
     scanRowFunc = GoFuncs{
         Name:    "ScanSingle" + tableNamePascalCase + "Row",
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    scanRowFunc.addArg(GoFuncArg{Name: "row", Type: "pgx.Row", IsPointer: true})
+    scanRowFunc.addArg(GoFuncArg{Name: "row", Type: dialect.RowType, IsPointer: true})
     scanRowFunc.addReturn(GoFuncReturn{Type: tableNamePascalCase, IsPointer: true})
     scanRowFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
@@ -502,10 +735,11 @@ func generateScanRow(table *metadata.Table, source *GoSourceFile) error {
 
     for i := range source.Structs[0].Fields {
         sf := source.Structs[0].Fields[i]
+        target := scanTarget(table, &table.Columns[i], tableNameCamelCase+"."+sf.Name)
         if i == len(source.Structs[0].Fields)-1 {
-            scanRowFunc.addLine("    &" + tableNameCamelCase + "." + sf.Name + ")")
+            scanRowFunc.addLine("    " + target + ")")
         } else {
-            scanRowFunc.addLine("    &" + tableNameCamelCase + "." + sf.Name + ",")
+            scanRowFunc.addLine("    " + target + ",")
         }
     }
 
@@ -518,15 +752,15 @@ func generateScanRow(table *metadata.Table, source *GoSourceFile) error {
 }
 
 func generateScanMultipleRows(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
     scanMultiRowsFunc := GoFuncs{
         Name:    "ScanAll" + tableNamePascalCase + "Rows",
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    scanMultiRowsFunc.addArg(GoFuncArg{Name: "rows", Type: "pgx.Rows", IsPointer: true})
+    scanMultiRowsFunc.addArg(GoFuncArg{Name: "rows", Type: dialect.RowsType, IsPointer: true})
     scanMultiRowsFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
     scanMultiRowsFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
@@ -547,21 +781,27 @@ func generateScanMultipleRows(table *metadata.Table, source *GoSourceFile) error
 }
 
 func generateSelectAll(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
     selectAllFunc := GoFuncs{
         Name:    "SelectAll" + tableNamePascalCase,
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    selectAllFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    selectAllFunc.addArg(ctxArg())
+    selectAllFunc.addArg(connArg())
     selectAllFunc.addArg(GoFuncArg{Name: "limit", Type: "uint", IsPointer: false})
     selectAllFunc.addArg(GoFuncArg{Name: "offset", Type: "uint", IsPointer: false})
     selectAllFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
     selectAllFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
-    sql := "SELECT * FROM " + table.Name + " LIMIT $1 OFFSET $2"
-    selectAllFunc.addLine("rows, err := conn.Query(context.Background(), \"" + sql + "\", limit, offset)")
+    // without an order, rows could be repeated or skipped from a page to the next
+    sortKey, err := sortKeyColumns(table)
+    if err != nil {
+        return err
+    }
+    sql := selectFrom(table) + orderBy(sortKey) + " LIMIT " + dialect.placeholder(1) + " OFFSET " + dialect.placeholder(2)
+    selectAllFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, \"" + sql + "\", limit, offset)")
     addIfErr(&selectAllFunc, "error scanning row: %w", 0)
     selectAllFunc.addLine("defer rows.Close()")
     selectAllFunc.addLine("")
@@ -572,14 +812,15 @@ func generateSelectAll(table *metadata.Table, source *GoSourceFile) error {
 }
 
 func generateSelectByPK(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
     selectByPKFunc := GoFuncs{
         Name:    "Select" + tableNamePascalCase + "ByPK",
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    selectByPKFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    selectByPKFunc.addArg(ctxArg())
+    selectByPKFunc.addArg(connArg())
 
     var pks []*metadata.Column
     for i := range table.Columns {
@@ -592,7 +833,7 @@ func generateSelectByPK(table *metadata.Table, source *GoSourceFile) error {
     for i := range pks {
         selectByPKFunc.addArg(GoFuncArg{
             Name:      metadata.ToCamelCase(pks[i].Name),
-            Type:      pgsql.PostgreSQLToGolangTypes[pks[i].Datatype],
+            Type:      fieldGoType(table, pks[i]),
             IsPointer: false,
         })
     }
@@ -600,17 +841,17 @@ func generateSelectByPK(table *metadata.Table, source *GoSourceFile) error {
     selectByPKFunc.addReturn(GoFuncReturn{Type: tableNamePascalCase, IsPointer: true})
     selectByPKFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
-    sql := "SELECT * FROM " + table.Name + " WHERE true "
+    sql := selectFrom(table) + " WHERE true "
     count := 0
     for i := range table.Columns {
         var col = table.Columns[i]
         if col.IsPrimaryKey {
             count += 1
-            sql += fmt.Sprintf(" AND %s = $%d", col.Name, count)
+            sql += fmt.Sprintf(" AND %s = %s", quotedIdent(col.Name), dialect.placeholder(count))
         }
     }
-    selectByPKFunc.addLine("row := conn.QueryRow(")
-    selectByPKFunc.addLine("    context.Background(),")
+    selectByPKFunc.addLine("row := conn." + dialect.QueryRowFunc + "(")
+    selectByPKFunc.addLine("    ctx,")
     selectByPKFunc.addLine("    \"" + sql + "\",")
 
     for i := range pks {
@@ -627,46 +868,66 @@ func generateSelectByPK(table *metadata.Table, source *GoSourceFile) error {
     return nil
 }
 
-func generateSelectByCol(col *metadata.Column, table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    selectByColFunc := GoFuncs{
-        Name:    "SelectAll" + tableNamePascalCase + "By" + metadata.ToPascalCase(col.Name),
-        Args:    make([]GoFuncArg, 0),
-        Returns: make([]GoFuncReturn, 0),
-        Lines:   make([]string, 0),
-    }
-    selectByColFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
-
+// columnArgName names the argument a column value is passed in, keeping
+// clear of Go keywords
+func columnArgName(col *metadata.Column) string {
     argName := metadata.ToCamelCase(col.Name)
     if col.Name == "type" {
         argName += "1"
     }
-    selectByColFunc.addArg(GoFuncArg{Name: argName, Type: pgsql.PostgreSQLToGolangTypes[col.Datatype], IsPointer: false})
-    selectByColFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
-    selectByColFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
-
-    sql := "SELECT * FROM " + table.Name + " WHERE " + col.Name + " = $1"
-    selectByColFunc.addLine("rows, err := conn.Query(context.Background(), \"" + sql + "\", " + argName + ")")
-    addIfErr(&selectByColFunc, "error scanning row: %w", 0)
-    selectByColFunc.addLine("defer rows.Close()")
-    selectByColFunc.addLine("")
-    selectByColFunc.addLine("return ScanAll" + tableNamePascalCase + "Rows(&rows)")
+    return argName
+}
 
-    source.addFunc(selectByColFunc)
+// autoIncrementColumn returns the first auto increment column of a table, if any
+func autoIncrementColumn(table *metadata.Table) *metadata.Column {
+    for i := range table.Columns {
+        if table.Columns[i].IsAutoIncrement {
+            return &table.Columns[i]
+        }
+    }
     return nil
 }
 
-func generateInsert(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+// addInsertQuery adds the query inserting a row of the table to a function,
+// which binds insertArgs to it
+func addInsertQuery(f *GoFuncs, table *metadata.Table) {
+    autoIncrementCol := autoIncrementColumn(table)
+    var insertCols []*metadata.Column
+    for i := range table.Columns {
+        if !table.Columns[i].IsAutoIncrement {
+            insertCols = append(insertCols, &table.Columns[i])
+        }
+    }
 
-    var autoIncrementCol *metadata.Column
+    f.addLine("query := `")
+    if len(insertCols) == 0 && dialect.isPostgres() {
+        f.addLine("    INSERT INTO " + rawTable(table) + " DEFAULT VALUES")
+    } else {
+        insertColumnsSQL(f, table, insertCols, false)
+    }
+    if autoIncrementCol != nil && dialect.HasReturning {
+        f.addLine("    RETURNING " + rawIdent(autoIncrementCol.Name))
+    }
+    f.addLine("`\n")
+}
+
+// insertArgs are the arguments of the insert query, taken from the fields of
+// the row variable
+func insertArgs(table *metadata.Table, row string) string {
+    args := ""
     for i := range table.Columns {
-        if table.Columns[i].IsAutoIncrement {
-            autoIncrementCol = &table.Columns[i]
-            break
+        if !table.Columns[i].IsAutoIncrement {
+            args += ", " + bindValue(table, &table.Columns[i], row+"."+metadata.ToPascalCase(table.Columns[i].Name))
         }
     }
+    return args
+}
+
+func generateInsert(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+
+    autoIncrementCol := autoIncrementColumn(table)
 
     insertFunc := GoFuncs{
         Name:    "Insert" + tableNamePascalCase,
@@ -674,199 +935,191 @@ func generateInsert(table *metadata.Table, source *GoSourceFile) error {
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    insertFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    insertFunc.addArg(ctxArg())
+    insertFunc.addArg(connArg())
     insertFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
     insertFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
-    insertFunc.addLine("query := `")
-    insertFunc.addLine("    INSERT INTO " + table.Name + " (")
-    for i := range table.Columns {
-        if table.Columns[i].IsAutoIncrement {
-            continue
-        }
-        if i < len(table.Columns)-1 {
-            insertFunc.addLine("        " + table.Columns[i].Name + ",")
-        } else {
-            insertFunc.addLine("        " + table.Columns[i].Name + ")")
-        }
-    }
-    insertFunc.addLine("    VALUES")
-    term := ""
-    count := 1
-    for i := range table.Columns {
-        if table.Columns[i].IsAutoIncrement {
-            continue
-        }
-        if i < len(table.Columns)-1 {
-            term += fmt.Sprintf("$%d,", count)
-        } else {
-            term += fmt.Sprintf("$%d)", count)
-        }
-        count += 1
-    }
-    insertFunc.addLine("        (" + term)
-    for i := range table.Columns {
-        if table.Columns[i].IsAutoIncrement {
-            insertFunc.addLine("    RETURNING " + table.Columns[i].Name)
-            break
-        }
-    }
-    insertFunc.addLine("`\n")
-
-    if autoIncrementCol != nil {
-        insertFunc.addLine("row := conn.QueryRow(context.Background(), query,")
-    } else {
-        insertFunc.addLine("_ = conn.QueryRow(context.Background(), query,")
-    }
-    for i := range table.Columns {
-        if table.Columns[i].IsAutoIncrement {
-            continue
-        }
-        if i < len(table.Columns)-1 {
-            insertFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(table.Columns[i].Name) + ",")
-        } else {
-            insertFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(table.Columns[i].Name) + ")")
-        }
-    }
-    if autoIncrementCol != nil {
-        insertFunc.addLine("")
-        insertFunc.addLine("var " + metadata.ToCamelCase(autoIncrementCol.Name) + " " + pgsql.PostgreSQLToGolangTypes[autoIncrementCol.Datatype])
-        insertFunc.addLine("err := row.Scan(&" + metadata.ToCamelCase(autoIncrementCol.Name) + ")")
+    addInsertQuery(&insertFunc, table)
+    args := insertArgs(table, tableNameCamelCase)
+
+    if autoIncrementCol != nil && dialect.HasReturning {
+        autoIncrementField := tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+        insertFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, query" + args + ")")
+        insertFunc.addLine("err := row.Scan(&" + autoIncrementField + ")")
+    } else if autoIncrementCol != nil {
+        // without RETURNING the generated id comes from LAST_INSERT_ID(), which
+        // database/sql exposes through the statement result
+        autoIncrementField := tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+        insertFunc.addLine("result, err := conn." + dialect.ExecFunc + "(ctx, query" + args + ")")
         insertFunc.addLine("if err != nil {")
-        insertFunc.addLine("    return nil")
+        insertFunc.addLine("    return fmt.Errorf(\"failed to perform insert: %w\", err)")
         insertFunc.addLine("}")
-        insertFunc.addLine(tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name) + " = " + metadata.ToCamelCase(autoIncrementCol.Name))
+        insertFunc.addLine("lastInsertId, err := result.LastInsertId()")
+        insertFunc.addLine("if err != nil {")
+        insertFunc.addLine("    return fmt.Errorf(\"failed to read last insert id: %w\", err)")
+        insertFunc.addLine("}")
+        insertFunc.addLine(autoIncrementField + " = " + fieldGoType(table, autoIncrementCol) + "(lastInsertId)")
+        insertFunc.addLine("return nil")
+        source.addFunc(insertFunc)
+        return nil
+    } else {
+        insertFunc.addLine("_, err := conn." + dialect.ExecFunc + "(ctx, query" + args + ")")
     }
-
+    insertFunc.addLine("if err != nil {")
+    insertFunc.addLine("    return fmt.Errorf(\"failed to perform insert: %w\", err)")
+    insertFunc.addLine("}")
     insertFunc.addLine("return nil")
 
     source.addFunc(insertFunc)
     return nil
 }
 
-func generateUpdate(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
-
+// primaryKeyColumns returns the primary key columns of a table
+func primaryKeyColumns(table *metadata.Table) []*metadata.Column {
     var primaryKeys []*metadata.Column
     for i := range table.Columns {
         if table.Columns[i].IsPrimaryKey {
             primaryKeys = append(primaryKeys, &table.Columns[i])
         }
     }
+    return primaryKeys
+}
 
-    updateFunc := GoFuncs{
-        Name:    "Update" + tableNamePascalCase,
-        Args:    make([]GoFuncArg, 0),
-        Returns: make([]GoFuncReturn, 0),
-        Lines:   make([]string, 0),
-    }
-    updateFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
-    updateFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
-    updateFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
-
-    updateFunc.addLine("query := `")
-    updateFunc.addLine("    UPDATE " + table.Name)
-    updateFunc.addLine("    SET")
+// addUpdateQuery adds the query updating a row of the table by primary key to
+// a function, which binds updateArgs to it
+func addUpdateQuery(f *GoFuncs, table *metadata.Table) {
+    f.addLine("query := `")
+    f.addLine("    UPDATE " + rawTable(table))
+    f.addLine("    SET")
     count := 1
+    setTerms := make([]string, 0)
     for i := range table.Columns {
         if table.Columns[i].IsPrimaryKey {
             continue
         }
-        if i < len(table.Columns)-1 {
-            updateFunc.addLine("        " + table.Columns[i].Name + fmt.Sprintf(" = $%d,", count))
+        setTerms = append(setTerms, "        "+rawIdent(table.Columns[i].Name)+" = "+dialect.placeholder(count))
+        count += 1
+    }
+    for i := range setTerms {
+        if i < len(setTerms)-1 {
+            f.addLine(setTerms[i] + ",")
         } else {
-            updateFunc.addLine("        " + table.Columns[i].Name + fmt.Sprintf(" = $%d", count))
+            f.addLine(setTerms[i])
         }
-        count += 1
     }
 
+    primaryKeys := primaryKeyColumns(table)
     term := "    WHERE true"
     for i := range primaryKeys {
-        term += " AND " + primaryKeys[i].Name + fmt.Sprintf(" = $%d", count)
+        term += " AND " + rawIdent(primaryKeys[i].Name) + " = " + dialect.placeholder(count)
+        count += 1
     }
-    updateFunc.addLine(term)
-    updateFunc.addLine("`\n")
+    f.addLine(term)
+    f.addLine("`\n")
+}
 
-    updateFunc.addLine("_, err := conn.Exec(context.Background(), query,")
+// updateArgs are the arguments of the update query, the columns set followed
+// by the primary key, taken from the fields of the row variable
+func updateArgs(table *metadata.Table, row string) string {
+    args := ""
     for i := range table.Columns {
         if table.Columns[i].IsPrimaryKey {
             continue
         }
-        updateFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(table.Columns[i].Name) + ",")
+        args += ", " + bindValue(table, &table.Columns[i], row+"."+metadata.ToPascalCase(table.Columns[i].Name))
     }
+    primaryKeys := primaryKeyColumns(table)
     for i := range primaryKeys {
-        if i < len(primaryKeys)-1 {
-            updateFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(primaryKeys[i].Name) + ",")
-        } else {
-            updateFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(primaryKeys[i].Name) + ")")
-        }
+        args += ", " + row + "." + metadata.ToPascalCase(primaryKeys[i].Name)
     }
-
-    updateFunc.addLine("if err != nil {")
-    updateFunc.addLine("    return fmt.Errorf(\"failed to perform update: %w\", err)")
-    updateFunc.addLine("}")
-    updateFunc.addLine("return nil")
-
-    source.addFunc(updateFunc)
-    return nil
+    return args
 }
 
-func generateDelete(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
-
-    var primaryKeys []*metadata.Column
-    for i := range table.Columns {
-        if table.Columns[i].IsPrimaryKey {
-            primaryKeys = append(primaryKeys, &table.Columns[i])
-        }
-    }
+func generateUpdate(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
 
     updateFunc := GoFuncs{
-        Name:    "Delete" + tableNamePascalCase,
+        Name:    "Update" + tableNamePascalCase,
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    updateFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    updateFunc.addArg(ctxArg())
+    updateFunc.addArg(connArg())
     updateFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
     updateFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
-    updateFunc.addLine("query := `")
-    updateFunc.addLine("    DELETE FROM " + table.Name)
+    addUpdateQuery(&updateFunc, table)
+    updateFunc.addLine("_, err := conn." + dialect.ExecFunc + "(ctx, query" + updateArgs(table, tableNameCamelCase) + ")")
+    updateFunc.addLine("if err != nil {")
+    updateFunc.addLine("    return fmt.Errorf(\"failed to perform update: %w\", err)")
+    updateFunc.addLine("}")
+    updateFunc.addLine("return nil")
+
+    source.addFunc(updateFunc)
+    return nil
+}
+
+// addDeleteQuery adds the query deleting a row of the table by primary key to
+// a function, which binds deleteArgs to it
+func addDeleteQuery(f *GoFuncs, table *metadata.Table) {
+    f.addLine("query := `")
+    f.addLine("    DELETE FROM " + rawTable(table))
+    primaryKeys := primaryKeyColumns(table)
     term := "    WHERE "
     count := 1
     for i := range primaryKeys {
         if i > 0 {
             term += " AND "
         }
-        term += primaryKeys[i].Name + fmt.Sprintf(" = $%d", count)
+        term += rawIdent(primaryKeys[i].Name) + " = " + dialect.placeholder(count)
         count += 1
     }
-    updateFunc.addLine(term)
-    updateFunc.addLine("`\n")
+    f.addLine(term)
+    f.addLine("`\n")
+}
 
-    updateFunc.addLine("_, err := conn.Exec(context.Background(), query,")
+// deleteArgs are the arguments of the delete query, the primary key taken
+// from the fields of the row variable
+func deleteArgs(table *metadata.Table, row string) string {
+    args := ""
+    primaryKeys := primaryKeyColumns(table)
     for i := range primaryKeys {
-        if i < len(primaryKeys)-1 {
-            updateFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(primaryKeys[i].Name) + ",")
-        } else {
-            updateFunc.addLine("    " + tableNameCamelCase + "." + metadata.ToPascalCase(primaryKeys[i].Name) + ")")
-        }
+        args += ", " + row + "." + metadata.ToPascalCase(primaryKeys[i].Name)
     }
+    return args
+}
 
-    updateFunc.addLine("if err != nil {")
-    updateFunc.addLine("    return fmt.Errorf(\"failed to perform update: %w\", err)")
-    updateFunc.addLine("}")
-    updateFunc.addLine("return nil")
+func generateDelete(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
 
-    source.addFunc(updateFunc)
+    deleteFunc := GoFuncs{
+        Name:    "Delete" + tableNamePascalCase,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    deleteFunc.addArg(ctxArg())
+    deleteFunc.addArg(connArg())
+    deleteFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
+    deleteFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    addDeleteQuery(&deleteFunc, table)
+    deleteFunc.addLine("_, err := conn." + dialect.ExecFunc + "(ctx, query" + deleteArgs(table, tableNameCamelCase) + ")")
+    deleteFunc.addLine("if err != nil {")
+    deleteFunc.addLine("    return fmt.Errorf(\"failed to perform delete: %w\", err)")
+    deleteFunc.addLine("}")
+    deleteFunc.addLine("return nil")
+
+    source.addFunc(deleteFunc)
     return nil
 }
 
 func generateExists(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
 
     var primaryKeys []*metadata.Column
     for i := range table.Columns {
@@ -881,12 +1134,13 @@ func generateExists(table *metadata.Table, source *GoSourceFile) error {
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    existsFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    existsFunc.addArg(ctxArg())
+    existsFunc.addArg(connArg())
 
     for i := range primaryKeys {
         existsFunc.addArg(GoFuncArg{
             Name:      metadata.ToCamelCase(primaryKeys[i].Name),
-            Type:      pgsql.PostgreSQLToGolangTypes[primaryKeys[i].Datatype],
+            Type:      fieldGoType(table, primaryKeys[i]),
             IsPointer: false,
         })
     }
@@ -896,20 +1150,20 @@ func generateExists(table *metadata.Table, source *GoSourceFile) error {
 
     existsFunc.addLine("query := `")
     existsFunc.addLine("    SELECT count(*)")
-    existsFunc.addLine("    FROM " + table.Name)
+    existsFunc.addLine("    FROM " + rawTable(table))
     term := "    WHERE "
     count := 1
     for i := range primaryKeys {
         if i > 0 {
             term += " AND "
         }
-        term += primaryKeys[i].Name + fmt.Sprintf(" = $%d", count)
+        term += rawIdent(primaryKeys[i].Name) + " = " + dialect.placeholder(count)
         count += 1
     }
     existsFunc.addLine(term)
     existsFunc.addLine("`\n")
 
-    term = "row := conn.QueryRow(context.Background(), query"
+    term = "row := conn." + dialect.QueryRowFunc + "(ctx, query"
     for i := range primaryKeys {
         term += ", " + metadata.ToCamelCase(primaryKeys[i].Name)
     }
@@ -926,51 +1180,357 @@ func generateExists(table *metadata.Table, source *GoSourceFile) error {
     return nil
 }
 
+// insertColumnsSQL adds the column list and the VALUES clause of an insert
+// of cols to a function. Overriding lets PostgreSQL take the given values of
+// identity columns generated ALWAYS.
+func insertColumnsSQL(f *GoFuncs, table *metadata.Table, cols []*metadata.Column, overriding bool) {
+    f.addLine("    INSERT INTO " + rawTable(table) + " (")
+    term := ""
+    for i := range cols {
+        if i < len(cols)-1 {
+            f.addLine("        " + rawIdent(cols[i].Name) + ",")
+            term += dialect.placeholder(i+1) + ","
+        } else {
+            f.addLine("        " + rawIdent(cols[i].Name))
+            term += dialect.placeholder(i + 1)
+        }
+    }
+    f.addLine("    )")
+    if overriding {
+        f.addLine("    OVERRIDING SYSTEM VALUE")
+    }
+    f.addLine("    VALUES")
+    f.addLine("        (" + term + ")")
+}
+
+// upsertTarget is a set of columns an upsert resolves conflicts on, and the
+// suffix of the function doing so
+type upsertTarget struct {
+    cols       []*metadata.Column
+    nameSuffix string
+}
+
+// upsertTargets returns the conflict targets of a table: its primary key, or
+// else its first unique constraint, for UpsertX, then its other unique
+// constraints for UpsertXOnY. MySQL can't choose the unique key an upsert
+// resolves conflicts on, so it only gets UpsertX.
+func upsertTargets(table *metadata.Table) ([]upsertTarget, error) {
+    keys := make([][]*metadata.Column, 0)
+    if pks := primaryKeyColumns(table); len(pks) > 0 {
+        keys = append(keys, pks)
+    }
+    for _, constraint := range table.SearchConstraints(metadata.UniqueConstraint) {
+        key := make([]*metadata.Column, 0)
+        for i := range constraint.Columns {
+            col := table.SearchColumnByName(constraint.Columns[i])
+            if col == nil {
+                return nil, fmt.Errorf("column %s of constraint %s not found in table %s", constraint.Columns[i], constraint.Name, table.Name)
+            }
+            key = append(key, col)
+        }
+        keys = append(keys, key)
+    }
+
+    targets := make([]upsertTarget, 0)
+    for i := range keys {
+        if i > 0 && dialect.DBMS == "MySQL" {
+            break
+        }
+        target := upsertTarget{cols: keys[i]}
+        if i > 0 {
+            columnNames := make([]string, 0)
+            for j := range keys[i] {
+                columnNames = append(columnNames, metadata.ToPascalCase(keys[i][j].Name))
+            }
+            target.nameSuffix = "On" + strings.Join(columnNames, "And")
+        }
+        targets = append(targets, target)
+    }
+    return targets, nil
+}
+
+// generateUpsert adds, for every conflict target, an upsert inserting a row
+// or updating the one it conflicts with in a single statement
 func generateUpsert(table *metadata.Table, source *GoSourceFile) error {
-    tableNamePascalCase := metadata.ToPascalCase(table.Name)
-    tableNameCamelCase := metadata.ToCamelCase(table.Name)
+    targets, err := upsertTargets(table)
+    if err != nil {
+        return err
+    }
+    for i := range targets {
+        generateUpsertOn(table, &targets[i], source)
+    }
+    return nil
+}
 
-    var primaryKeys []*metadata.Column
+// generateUpsertOn adds the upsert of a conflict target. Auto increment
+// columns holding zero are left to the database, and the key of the inserted
+// or updated row is read back into the given one. Keys and the target are
+// never updated.
+func generateUpsertOn(table *metadata.Table, target *upsertTarget, source *GoSourceFile) {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+    autoIncrementCol := autoIncrementColumn(table)
+
+    var insertCols []*metadata.Column
+    var allCols []*metadata.Column
+    var updateCols []*metadata.Column
     for i := range table.Columns {
-        if table.Columns[i].IsPrimaryKey {
-            primaryKeys = append(primaryKeys, &table.Columns[i])
+        col := &table.Columns[i]
+        allCols = append(allCols, col)
+        if col.IsAutoIncrement {
+            continue
+        }
+        insertCols = append(insertCols, col)
+        isTarget := false
+        for j := range target.cols {
+            isTarget = isTarget || target.cols[j] == col
+        }
+        if !col.IsPrimaryKey && !isTarget {
+            updateCols = append(updateCols, col)
         }
     }
+    // a conflicting row must still be updated for RETURNING to report it
+    if len(updateCols) == 0 && (dialect.DBMS != "MySQL" || autoIncrementCol == nil) {
+        updateCols = target.cols
+    }
 
     upsertFunc := GoFuncs{
-        Name:    "Upsert" + tableNamePascalCase,
+        Name:    "Upsert" + tableNamePascalCase + target.nameSuffix,
         Args:    make([]GoFuncArg, 0),
         Returns: make([]GoFuncReturn, 0),
         Lines:   make([]string, 0),
     }
-    upsertFunc.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+    upsertFunc.addArg(ctxArg())
+    upsertFunc.addArg(connArg())
     upsertFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
     upsertFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
 
-    term := "exists, err := Exists" + tableNamePascalCase + "(conn"
-    for i := range primaryKeys {
-        term += ", " + tableNameCamelCase + "." + metadata.ToPascalCase(primaryKeys[i].Name)
+    addQuery := func(indent string, assign string, cols []*metadata.Column, overriding bool) {
+        upsertFunc.addLine(indent + "query " + assign + " `")
+        insertColumnsSQL(&upsertFunc, table, cols, overriding)
+        updates := make([]string, 0)
+        if dialect.DBMS == "MySQL" {
+            upsertFunc.addLine("    ON DUPLICATE KEY UPDATE")
+            for i := range updateCols {
+                updates = append(updates, rawIdent(updateCols[i].Name)+" = VALUES("+rawIdent(updateCols[i].Name)+")")
+            }
+            // LAST_INSERT_ID() then reports the key of the updated row
+            if autoIncrementCol != nil {
+                updates = append(updates, rawIdent(autoIncrementCol.Name)+" = LAST_INSERT_ID("+rawIdent(autoIncrementCol.Name)+")")
+            }
+        } else {
+            conflictCols := make([]string, 0)
+            for i := range target.cols {
+                conflictCols = append(conflictCols, rawIdent(target.cols[i].Name))
+            }
+            upsertFunc.addLine("    ON CONFLICT (" + strings.Join(conflictCols, ", ") + ") DO UPDATE SET")
+            for i := range updateCols {
+                updates = append(updates, rawIdent(updateCols[i].Name)+" = excluded."+rawIdent(updateCols[i].Name))
+            }
+        }
+        for i := range updates {
+            if i < len(updates)-1 {
+                upsertFunc.addLine("        " + updates[i] + ",")
+            } else {
+                upsertFunc.addLine("        " + updates[i])
+            }
+        }
+        if dialect.HasReturning {
+            upsertFunc.addLine("    RETURNING ` + " + columnListName(table) + " + `")
+        }
+        upsertFunc.addLine("`")
+    }
+    argList := func(cols []*metadata.Column) string {
+        args := make([]string, 0)
+        for i := range cols {
+            args = append(args, bindValue(table, cols[i], tableNameCamelCase+"."+metadata.ToPascalCase(cols[i].Name)))
+        }
+        return "[]any{" + strings.Join(args, ", ") + "}"
     }
-    upsertFunc.addLine(term + ")")
 
+    autoIncrementField := ""
+    if autoIncrementCol != nil {
+        autoIncrementField = tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+        addQuery("", ":=", insertCols, false)
+        upsertFunc.addLine("args := " + argList(insertCols))
+        upsertFunc.addLine("// a zero " + autoIncrementCol.Name + " is left to the database")
+        upsertFunc.addLine("if " + autoIncrementField + " != 0 {")
+        addQuery("    ", "=", allCols, dialect.isPostgres())
+        upsertFunc.addLine("    args = " + argList(allCols))
+        upsertFunc.addLine("}")
+    } else {
+        addQuery("", ":=", allCols, false)
+        upsertFunc.addLine("args := " + argList(allCols))
+    }
+    upsertFunc.addLine("")
+
+    if dialect.HasReturning {
+        // the stored row replaces the given one, as defaults and triggers may have changed it
+        upsertFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, query, args...)")
+        upsertFunc.addLine("upserted, err := ScanSingle" + tableNamePascalCase + "Row(&row)")
+        upsertFunc.addLine("if err != nil {")
+        upsertFunc.addLine("    return fmt.Errorf(\"failed to perform upsert: %w\", err)")
+        upsertFunc.addLine("}")
+        upsertFunc.addLine("*" + tableNameCamelCase + " = *upserted")
+        upsertFunc.addLine("return nil")
+        source.addFunc(upsertFunc)
+        return
+    }
+
+    if autoIncrementCol == nil {
+        upsertFunc.addLine("_, err := conn." + dialect.ExecFunc + "(ctx, query, args...)")
+        upsertFunc.addLine("if err != nil {")
+        upsertFunc.addLine("    return fmt.Errorf(\"failed to perform upsert: %w\", err)")
+        upsertFunc.addLine("}")
+        upsertFunc.addLine("return nil")
+        source.addFunc(upsertFunc)
+        return
+    }
+
+    autoIncrementType := fieldGoType(table, autoIncrementCol)
+    upsertFunc.addLine("result, err := conn." + dialect.ExecFunc + "(ctx, query, args...)")
     upsertFunc.addLine("if err != nil {")
-    upsertFunc.addLine("    return err")
+    upsertFunc.addLine("    return fmt.Errorf(\"failed to perform upsert: %w\", err)")
     upsertFunc.addLine("}")
-
-    upsertFunc.addLine("if exists {")
-    upsertFunc.addLine("    err = Update" + tableNamePascalCase + "(conn, " + tableNameCamelCase + ")")
-    upsertFunc.addLine("    if err != nil {")
-    upsertFunc.addLine("        return err")
-    upsertFunc.addLine("    }")
-    upsertFunc.addLine("} else {")
-    upsertFunc.addLine("    err = Insert" + tableNamePascalCase + "(conn, " + tableNameCamelCase + ")")
-    upsertFunc.addLine("    if err != nil {")
-    upsertFunc.addLine("        return err")
-    upsertFunc.addLine("    }")
+    if dialect.DBMS == "MySQL" {
+        upsertFunc.addLine("lastInsertId, err := result.LastInsertId()")
+        upsertFunc.addLine("if err != nil {")
+        upsertFunc.addLine("    return fmt.Errorf(\"failed to read last insert id: %w\", err)")
+        upsertFunc.addLine("}")
+        upsertFunc.addLine("if lastInsertId != 0 {")
+        upsertFunc.addLine("    " + autoIncrementField + " = " + autoIncrementType + "(lastInsertId)")
+        upsertFunc.addLine("}")
+        upsertFunc.addLine("return nil")
+        source.addFunc(upsertFunc)
+        return
+    }
+
+    upsertFunc.addLine("if " + autoIncrementField + " != 0 {")
+    upsertFunc.addLine("    return nil")
     upsertFunc.addLine("}")
+    upsertFunc.addLine("lastInsertId, err := result.LastInsertId()")
+    upsertFunc.addLine("if err != nil {")
+    upsertFunc.addLine("    return fmt.Errorf(\"failed to read last insert id: %w\", err)")
+    upsertFunc.addLine("}")
+    upsertFunc.addLine(autoIncrementField + " = " + autoIncrementType + "(lastInsertId)")
+    if !autoIncrementCol.IsPrimaryKey || len(target.cols) != 1 || target.cols[0] != autoIncrementCol {
+        // an update leaves the last insert id alone, so the key of a
+        // conflicting row is selected by the values of the target
+        if !metadata.ContainsString(source.Imports, "errors") {
+            source.addImport("errors")
+        }
+        conditions := make([]string, 0)
+        for i := range target.cols {
+            conditions = append(conditions, quotedIdent(target.cols[i].Name)+" = "+dialect.placeholder(i+1))
+        }
+        sql := "SELECT " + quotedIdent(autoIncrementCol.Name) + " FROM " + quotedTable(table) + " WHERE " + strings.Join(conditions, " AND ")
+        upsertFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, \"" + sql + "\", " + strings.TrimSuffix(strings.TrimPrefix(argList(target.cols), "[]any{"), "}") + ")")
+        upsertFunc.addLine("err = row.Scan(&" + autoIncrementField + ")")
+        upsertFunc.addLine("if err != nil && !errors.Is(err, sql.ErrNoRows) {")
+        upsertFunc.addLine("    return fmt.Errorf(\"failed to read upserted key: %w\", err)")
+        upsertFunc.addLine("}")
+    }
     upsertFunc.addLine("return nil")
 
     source.addFunc(upsertFunc)
+}
+
+// generateInsertIgnoreConflict inserts a row unless it conflicts with one
+// already stored on any unique constraint, telling whether it was inserted
+func generateInsertIgnoreConflict(table *metadata.Table, source *GoSourceFile) error {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+
+    var autoIncrementCol *metadata.Column
+    var insertCols []*metadata.Column
+    for i := range table.Columns {
+        if table.Columns[i].IsAutoIncrement {
+            if autoIncrementCol == nil {
+                autoIncrementCol = &table.Columns[i]
+            }
+        } else {
+            insertCols = append(insertCols, &table.Columns[i])
+        }
+    }
+    if len(insertCols) == 0 {
+        return nil
+    }
+
+    insertFunc := GoFuncs{
+        Name:    "Insert" + tableNamePascalCase + "IgnoreConflict",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    insertFunc.addArg(ctxArg())
+    insertFunc.addArg(connArg())
+    insertFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
+    insertFunc.addReturn(GoFuncReturn{Type: "bool", IsPointer: false})
+    insertFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    insertFunc.addLine("query := `")
+    insertColumnsSQL(&insertFunc, table, insertCols, false)
+    if dialect.DBMS == "MySQL" {
+        // unlike INSERT IGNORE, a no-op update doesn't swallow other errors
+        insertFunc.addLine("    ON DUPLICATE KEY UPDATE " + rawIdent(insertCols[0].Name) + " = " + rawIdent(insertCols[0].Name))
+    } else {
+        insertFunc.addLine("    ON CONFLICT DO NOTHING")
+    }
+    if autoIncrementCol != nil && dialect.HasReturning {
+        insertFunc.addLine("    RETURNING " + rawIdent(autoIncrementCol.Name))
+    }
+    insertFunc.addLine("`\n")
+
+    args := ""
+    for i := range insertCols {
+        args += ", " + bindValue(table, insertCols[i], tableNameCamelCase+"."+metadata.ToPascalCase(insertCols[i].Name))
+    }
+
+    if autoIncrementCol != nil && dialect.HasReturning {
+        // a skipped row returns nothing
+        if !metadata.ContainsString(source.Imports, "errors") {
+            source.addImport("errors")
+        }
+        autoIncrementField := tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+        insertFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, query" + args + ")")
+        insertFunc.addLine("err := row.Scan(&" + autoIncrementField + ")")
+        insertFunc.addLine("if errors.Is(err, pgx.ErrNoRows) {")
+        insertFunc.addLine("    return false, nil")
+        insertFunc.addLine("}")
+        insertFunc.addLine("if err != nil {")
+        insertFunc.addLine("    return false, fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("}")
+        insertFunc.addLine("return true, nil")
+    } else if dialect.isPostgres() {
+        insertFunc.addLine("tag, err := conn." + dialect.ExecFunc + "(ctx, query" + args + ")")
+        insertFunc.addLine("if err != nil {")
+        insertFunc.addLine("    return false, fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("}")
+        insertFunc.addLine("return tag.RowsAffected() > 0, nil")
+    } else {
+        insertFunc.addLine("result, err := conn." + dialect.ExecFunc + "(ctx, query" + args + ")")
+        insertFunc.addLine("if err != nil {")
+        insertFunc.addLine("    return false, fmt.Errorf(\"failed to perform insert: %w\", err)")
+        insertFunc.addLine("}")
+        insertFunc.addLine("rowsAffected, err := result.RowsAffected()")
+        insertFunc.addLine("if err != nil {")
+        insertFunc.addLine("    return false, fmt.Errorf(\"failed to read rows affected: %w\", err)")
+        insertFunc.addLine("}")
+        insertFunc.addLine("if rowsAffected == 0 {")
+        insertFunc.addLine("    return false, nil")
+        insertFunc.addLine("}")
+        if autoIncrementCol != nil {
+            autoIncrementField := tableNameCamelCase + "." + metadata.ToPascalCase(autoIncrementCol.Name)
+            insertFunc.addLine("lastInsertId, err := result.LastInsertId()")
+            insertFunc.addLine("if err != nil {")
+            insertFunc.addLine("    return false, fmt.Errorf(\"failed to read last insert id: %w\", err)")
+            insertFunc.addLine("}")
+            insertFunc.addLine(autoIncrementField + " = " + fieldGoType(table, autoIncrementCol) + "(lastInsertId)")
+        }
+        insertFunc.addLine("return true, nil")
+    }
+
+    source.addFunc(insertFunc)
     return nil
 }
 
@@ -979,9 +1539,9 @@ func generateGoDTO(folder string, packageName string, table metadata.Table) erro
 
     // init go source struct
     source := GoSourceFile{
-        Name:    table.Name,
+        Name:    baseName(&table),
         Package: packageName,
-        Imports: []string{"context", "fmt", "github.com/jackc/pgx/v5"},
+        Imports: append([]string{"context", "fmt"}, dialect.Imports...),
         Structs: make([]GoStruct, 0),
         Funcs:   make([]GoFuncs, 0),
     }
@@ -1020,21 +1580,34 @@ func generateGoDTO(folder string, packageName string, table metadata.Table) erro
         return err
     }
 
+    // generate keyset pagination
+    err = generateSelectPage(&table, &source)
+    if err != nil {
+        return err
+    }
+
     // generate select by pk
     err = generateSelectByPK(&table, &source)
     if err != nil {
         return err
     }
 
-    // generate select by columns that are not pk
-    for i := range table.Columns {
-        if table.Columns[i].IsPrimaryKey {
-            continue
-        }
-        err = generateSelectByCol(&table.Columns[i], &table, &source)
-        if err != nil {
-            return err
-        }
+    // generate finders by indexed columns
+    err = generateFinders(&table, &source)
+    if err != nil {
+        return err
+    }
+
+    // generate select by filter
+    err = generateFilter(&table, &source)
+    if err != nil {
+        return err
+    }
+
+    // generate navigation along foreign keys
+    err = generateRelations(&table, &source)
+    if err != nil {
+        return err
     }
 
     // generate insert
@@ -1055,7 +1628,7 @@ func generateGoDTO(folder string, packageName string, table metadata.Table) erro
         return err
     }
 
-    // if table has no autoinc col, we can generate exists query and upsert
+    // if table has no autoinc col, we can generate exists query
     hasAutoinc := false
     for i := range table.Columns {
         if table.Columns[i].IsAutoIncrement {
@@ -1064,17 +1637,36 @@ func generateGoDTO(folder string, packageName string, table metadata.Table) erro
         }
     }
     if !hasAutoinc {
-        // generate exists query
         err = generateExists(&table, &source)
         if err != nil {
             return err
         }
+    }
 
-        // generate upsert query
-        err = generateUpsert(&table, &source)
-        if err != nil {
-            return err
-        }
+    // generate upsert, and insert skipping conflicting rows
+    err = generateUpsert(&table, &source)
+    if err != nil {
+        return err
+    }
+    err = generateInsertIgnoreConflict(&table, &source)
+    if err != nil {
+        return err
+    }
+
+    // generate bulk inserts
+    err = generateCopyInsert(&table, &source)
+    if err != nil {
+        return err
+    }
+    err = generateInsertMany(&table, &source)
+    if err != nil {
+        return err
+    }
+
+    // generate batched insert, update and delete
+    err = generateQueueFuncs(&table, &source)
+    if err != nil {
+        return err
     }
 
     // write final text file
@@ -1093,7 +1685,7 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
     source := GoSourceFile{
         Name:    "custom_queries",
         Package: packageName,
-        Imports: []string{"context", "github.com/jackc/pgx/v5"},
+        Imports: []string{"context"},
         Structs: make([]GoStruct, 0),
         Funcs:   make([]GoFuncs, 0),
     }
@@ -1105,6 +1697,9 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
             Name:   metadata.ToPascalCase(cq.Name) + "Result",
             Fields: make([]GoStructField, 0),
         }
+        // tables and columns of the struct fields, for their scan targets
+        resTables := make([]*metadata.Table, 0)
+        resColumns := make([]*metadata.Column, 0)
 
         // fill struct fields
         if len(cq.ProjectionColumns) > 1 {
@@ -1124,9 +1719,12 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
                         if col.Nullable {
                             prefix = "*"
                         }
+                        addFieldImports(t, &t.Columns[k], &source)
+                        resTables = append(resTables, t)
+                        resColumns = append(resColumns, &t.Columns[k])
                         resS.addField(GoStructField{
                             Name: metadata.ToPascalCase(col.Table) + metadata.ToPascalCase(t.Columns[k].Name),
-                            Type: prefix + pgsql.PostgreSQLToGolangTypes[t.Columns[k].Datatype],
+                            Type: prefix + fieldGoType(t, &t.Columns[k]),
                             Annotation: &GoStructFieldAnnotation{
                                 Name:  "json",
                                 Value: col.Table + "_" + col.Column,
@@ -1145,25 +1743,37 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
         }
 
         // add func args
-        qf.addArg(GoFuncArg{Name: "conn", Type: "pgx.Conn", IsPointer: true})
+        qf.addArg(ctxArg())
+        qf.addArg(connArg())
         for j := range cq.Parameters {
             var p = cq.Parameters[j]
-            qf.addArg(GoFuncArg{Name: p.ParamName, Type: p.GoType, IsPointer: false})
+            // parameters may be declared with a database type the config overrides
+            paramType := p.GoType
+            if override := goTypeOverride(p.GoType); override != nil {
+                paramType = override.GoType
+            }
+            addTypeImports(paramType, &source)
+            qf.addArg(GoFuncArg{Name: p.ParamName, Type: paramType, IsPointer: false})
         }
 
         // add func returns
         projectionType := ""
         projIsPrimitiveType := true
+        // single column projections are scanned as the field of their column
+        resultTarget := func(v string) string { return "&" + v }
         if len(cq.ProjectionColumns) == 0 {
         } else if len(cq.ProjectionColumns) == 1 {
             col := cq.ProjectionColumns[0]
             if col.Table == "" {
-                projectionType = pgsql.PostgreSQLToGolangTypes[col.SQLType]
+                projectionType = goTypeOf(col.SQLType)
+                addTypeImports(projectionType, &source)
             } else if col.Table != "" && col.Column != "*" {
                 tableRef := meta.SearchTableByName(col.Table)
                 columnRef := tableRef.SearchColumnByName(col.Column)
-                projectionType = pgsql.PostgreSQLToGolangTypes[columnRef.Datatype]
+                projectionType = fieldGoType(tableRef, columnRef)
                 projIsPrimitiveType = true
+                addFieldImports(tableRef, columnRef, &source)
+                resultTarget = func(v string) string { return scanTarget(tableRef, columnRef, v) }
             } else {
                 projectionType = metadata.ToPascalCase(col.Table)
                 projIsPrimitiveType = false
@@ -1192,14 +1802,14 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
             params += ", " + cq.Parameters[i].ParamName
         }
         if cq.Cardinality == "0" {
-            qf.addLine("_, err := conn.Exec(context.Background(), query" + params + ")")
+            qf.addLine("_, err := conn." + dialect.ExecFunc + "(ctx, query" + params + ")")
             qf.addLine("if err != nil {")
             qf.addLine("    return err")
             qf.addLine("}")
         } else if cq.Cardinality == "1" {
-            qf.addLine("row := conn.QueryRow(context.Background(), query" + params + ")")
+            qf.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, query" + params + ")")
         } else if cq.Cardinality == "N" {
-            qf.addLine("rows, err := conn.Query(context.Background(), query" + params + ")")
+            qf.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, query" + params + ")")
             qf.addLine("if err != nil {")
             qf.addLine("    return nil, err")
             qf.addLine("}")
@@ -1215,7 +1825,7 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
                 qf.addLine("var result " + projectionType)
 
                 if projIsPrimitiveType {
-                    qf.addLine("err := row.Scan(&result)")
+                    qf.addLine("err := row.Scan(" + resultTarget("result") + ")")
                     qf.addLine("if err != nil {")
                     qf.addLine("    return result, err")
                     qf.addLine("}")
@@ -1223,10 +1833,11 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
                 } else {
                     qf.addLine("err := row.Scan(")
                     for i := range resS.Fields {
+                        target := scanTarget(resTables[i], resColumns[i], "result."+resS.Fields[i].Name)
                         if i < len(resS.Fields)-1 {
-                            qf.addLine("    &result." + resS.Fields[i].Name + ",")
+                            qf.addLine("    " + target + ",")
                         } else {
-                            qf.addLine("    &result." + resS.Fields[i].Name + ")")
+                            qf.addLine("    " + target + ")")
                         }
                     }
                     qf.addLine("if err != nil {")
@@ -1245,13 +1856,14 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
                 qf.addLine("    err := rows.Scan(")
 
                 if projIsPrimitiveType {
-                    qf.addLine("        &res)")
+                    qf.addLine("        " + resultTarget("res") + ")")
                 } else {
                     for i := range resS.Fields {
+                        target := scanTarget(resTables[i], resColumns[i], "res."+resS.Fields[i].Name)
                         if i < len(resS.Fields)-1 {
-                            qf.addLine("        &res." + resS.Fields[i].Name + ",")
+                            qf.addLine("        " + target + ",")
                         } else {
-                            qf.addLine("        &res." + resS.Fields[i].Name + ")")
+                            qf.addLine("        " + target + ")")
                         }
                     }
                 }
@@ -1281,11 +1893,20 @@ func generateCustomQueries(folder string, packageName string, meta *metadata.Met
     return nil
 }
 
-func WriteGolang(connInfo *config.ConnectionInfo, folder string, metadata *metadata.Metadata, customQueries []config.CustomQuery) error {
+func WriteGolang(config *config.Config, folder string, metadata *metadata.Metadata, customQueries []config.CustomQuery) error {
     fmt.Println("Generating DTO files on ", folder)
 
+    // pick the dialect the generated code is written for
+    var err error
+    dialect, err = dialectFor(config.ConnInfo.DBMS)
+    if err != nil {
+        return err
+    }
+    sourceMetadata = metadata
+    sourceConfig = config
+
     // remove existing .go files in the target directory
-    err := removeExistingGoFiles(folder)
+    err = removeExistingGoFiles(folder)
     if err != nil {
         return err
     }
@@ -1294,7 +1915,13 @@ func WriteGolang(connInfo *config.ConnectionInfo, folder string, metadata *metad
     packageName := parts[len(parts)-1]
 
     // generate connector source file
-    err = generateGoDbConnector(connInfo, folder, packageName)
+    err = generateGoDbConnector(&config.ConnInfo, folder, packageName)
+    if err != nil {
+        return err
+    }
+
+    // generate enum types
+    err = generateGoEnums(folder, packageName, metadata)
     if err != nil {
         return err
     }
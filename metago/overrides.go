@@ -0,0 +1,102 @@
+package metago
+
+import (
+    "dto-gen/config"
+    "dto-gen/metadata"
+    "dto-gen/pgsql"
+    "regexp"
+    "strings"
+)
+
+// ======================================================================================
+//     Type Overrides
+// ======================================================================================
+
+// config being generated, so types can be overridden
+var sourceConfig *config.Config
+
+// goColumnOverride returns the override replacing the go type of a column,
+// nil if the config has none
+func goColumnOverride(table *metadata.Table, col *metadata.Column) *config.TypeOverride {
+    if sourceConfig == nil {
+        return nil
+    }
+    override := sourceConfig.SearchColumnOverride(table.Schema, table.Name, col.Name)
+    if override == nil || override.GoType == "" {
+        return nil
+    }
+    return override
+}
+
+// goTypeOverride returns the override replacing the go type of a database
+// type, nil if the config has none. PostgreSQL types can be given by udt or
+// display name.
+func goTypeOverride(datatype string) *config.TypeOverride {
+    if sourceConfig == nil {
+        return nil
+    }
+    names := []string{datatype}
+    if dialect.isPostgres() {
+        if pgType := pgsql.SearchPgType(datatype); pgType != nil {
+            names = append(names, pgType.UdtName, pgType.DisplayName)
+        }
+    }
+    override := sourceConfig.SearchTypeOverride(names...)
+    if override == nil || override.GoType == "" {
+        return nil
+    }
+    return override
+}
+
+// isOverridden tells whether the go type of a column is replaced, either as a
+// column or through its database type
+func isOverridden(table *metadata.Table, col *metadata.Column) bool {
+    return goColumnOverride(table, col) != nil || goTypeOverride(dialect.typeNameOf(col)) != nil
+}
+
+// fieldGoType is the go type of the struct field of a column, taking column
+// overrides into account
+func fieldGoType(table *metadata.Table, col *metadata.Column) string {
+    if override := goColumnOverride(table, col); override != nil {
+        return override.GoType
+    }
+    return columnGoType(col)
+}
+
+// packages of the qualified go types the type maps refer to
+var typeImports = map[string]string{
+    "json":   "encoding/json",
+    "net":    "net",
+    "netip":  "net/netip",
+    "pgtype": "github.com/jackc/pgx/v5/pgtype",
+    "time":   "time",
+}
+
+var qualifierRegexp = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// addTypeImports adds the imports of the packages a go type refers to,
+// looking them up in the overrides before the type maps
+func addTypeImports(gotype string, source *GoSourceFile) {
+    matches := qualifierRegexp.FindAllStringSubmatch(gotype, -1)
+    for i := range matches {
+        qualifier := matches[i][1]
+        importPath := typeImports[qualifier]
+        if sourceConfig != nil {
+            overrides := sourceConfig.AllOverrides()
+            for j := range overrides {
+                if overrides[j].GoImport != "" && strings.HasPrefix(strings.TrimLeft(overrides[j].GoType, "*[]"), qualifier+".") {
+                    importPath = overrides[j].GoImport
+                    break
+                }
+            }
+        }
+        if importPath != "" && !metadata.ContainsString(source.Imports, importPath) {
+            source.addImport(importPath)
+        }
+    }
+}
+
+// addFieldImports adds the imports needed by the struct field of a column
+func addFieldImports(table *metadata.Table, col *metadata.Column, source *GoSourceFile) {
+    addTypeImports(fieldGoType(table, col), source)
+}
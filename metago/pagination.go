@@ -0,0 +1,291 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+    "fmt"
+    "strings"
+)
+
+// ======================================================================================
+//     Pagination
+// ======================================================================================
+
+// sortKeyColumns returns the columns rows of a table are ordered and paged
+// by, the configured sort key or else the primary key. Tables with neither
+// have no order. Pages skip or repeat rows unless the key is not null and
+// unique, as the primary key, a unique constraint or a unique index makes it.
+func sortKeyColumns(table *metadata.Table) ([]*metadata.Column, error) {
+    var columnNames []string
+    if sourceConfig != nil {
+        columnNames = sourceConfig.SearchSortKey(table.Schema, table.Name)
+    }
+    if len(columnNames) == 0 {
+        return primaryKeyColumns(table), nil
+    }
+    sortKey := make([]*metadata.Column, 0)
+    for i := range columnNames {
+        col := table.SearchColumnByName(columnNames[i])
+        if col == nil {
+            return nil, fmt.Errorf("sort key column %s not found in table %s", columnNames[i], table.Name)
+        }
+        if col.Nullable {
+            return nil, fmt.Errorf("sort key column %s of table %s is nullable", columnNames[i], table.Name)
+        }
+        sortKey = append(sortKey, col)
+    }
+    if !isUniqueKey(table, columnNames) {
+        return nil, fmt.Errorf("sort key (%s) of table %s is not unique: it must match its primary key, a unique constraint or a unique index",
+            strings.Join(columnNames, ", "), table.Name)
+    }
+    return sortKey, nil
+}
+
+// isUniqueKey reports whether columns, in any order, are those of the primary
+// key, of a unique constraint or of a unique index of a table
+func isUniqueKey(table *metadata.Table, columnNames []string) bool {
+    sameColumns := func(keyColumns []string) bool {
+        if len(keyColumns) != len(columnNames) {
+            return false
+        }
+        for i := range keyColumns {
+            if !metadata.ContainsString(columnNames, keyColumns[i]) {
+                return false
+            }
+        }
+        return true
+    }
+
+    pkNames := make([]string, 0)
+    for _, col := range primaryKeyColumns(table) {
+        pkNames = append(pkNames, col.Name)
+    }
+    if sameColumns(pkNames) {
+        return true
+    }
+    for _, constraint := range table.SearchConstraints(metadata.UniqueConstraint) {
+        if sameColumns(constraint.Columns) {
+            return true
+        }
+    }
+    for _, index := range table.Indexes {
+        if index.IsUnique && sameColumns(index.Columns) {
+            return true
+        }
+    }
+    return false
+}
+
+// orderBy is the ORDER BY clause of a sort key, empty without one
+func orderBy(sortKey []*metadata.Column) string {
+    if len(sortKey) == 0 {
+        return ""
+    }
+    columnNames := make([]string, 0)
+    for i := range sortKey {
+        columnNames = append(columnNames, quotedIdent(sortKey[i].Name))
+    }
+    return " ORDER BY " + strings.Join(columnNames, ", ")
+}
+
+// generateCursorType adds the Cursor the SelectXPage functions page with,
+// which carries the sort key of the last row of a page as json. Its token is
+// that json, base64 encoded so clients treat it as opaque.
+func generateCursorType(source *GoSourceFile) {
+    for _, i := range []string{"encoding/base64", "encoding/json"} {
+        if !metadata.ContainsString(source.Imports, i) {
+            source.addImport(i)
+        }
+    }
+
+    source.addDecl("// Cursor marks the last row of a page, the next page starting after it.\n" +
+        "// It is handed to clients as an opaque token, parsed back by ParseCursor.\n" +
+        "type Cursor struct {\n" +
+        "    key []byte\n" +
+        "}")
+
+    receiver := "c"
+
+    newFunc := GoFuncs{
+        Name:    "newCursor",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    newFunc.addArg(GoFuncArg{Name: "key", Type: "any", IsPointer: false})
+    newFunc.addReturn(GoFuncReturn{Type: "Cursor", IsPointer: true})
+    newFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    newFunc.addLine("data, err := json.Marshal(key)")
+    newFunc.addLine("if err != nil {")
+    newFunc.addLine("    return nil, fmt.Errorf(\"error encoding cursor: %w\", err)")
+    newFunc.addLine("}")
+    newFunc.addLine("return &Cursor{key: data}, nil")
+    source.addFunc(newFunc)
+
+    parseFunc := GoFuncs{
+        Name:    "ParseCursor",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    parseFunc.addArg(GoFuncArg{Name: "token", Type: "string", IsPointer: false})
+    parseFunc.addReturn(GoFuncReturn{Type: "Cursor", IsPointer: true})
+    parseFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    parseFunc.addLine("data, err := base64.RawURLEncoding.DecodeString(token)")
+    parseFunc.addLine("if err != nil || !json.Valid(data) {")
+    parseFunc.addLine("    return nil, fmt.Errorf(\"invalid cursor %q\", token)")
+    parseFunc.addLine("}")
+    parseFunc.addLine("return &Cursor{key: data}, nil")
+    source.addFunc(parseFunc)
+
+    stringFunc := GoFuncs{
+        Name:     "String",
+        Receiver: &GoFuncArg{Name: receiver, Type: "Cursor", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    stringFunc.addReturn(GoFuncReturn{Type: "string", IsPointer: false})
+    stringFunc.addLine("return base64.RawURLEncoding.EncodeToString(" + receiver + ".key)")
+    source.addFunc(stringFunc)
+
+    // text marshalling lets cursors be embedded as is in json responses and requests
+    marshalFunc := GoFuncs{
+        Name:     "MarshalText",
+        Receiver: &GoFuncArg{Name: receiver, Type: "Cursor", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    marshalFunc.addReturn(GoFuncReturn{Type: "[]byte", IsPointer: false})
+    marshalFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    marshalFunc.addLine("return []byte(" + receiver + ".String()), nil")
+    source.addFunc(marshalFunc)
+
+    unmarshalFunc := GoFuncs{
+        Name:     "UnmarshalText",
+        Receiver: &GoFuncArg{Name: receiver, Type: "Cursor", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    unmarshalFunc.addArg(GoFuncArg{Name: "text", Type: "[]byte", IsPointer: false})
+    unmarshalFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    unmarshalFunc.addLine("parsed, err := ParseCursor(string(text))")
+    unmarshalFunc.addLine("if err != nil {")
+    unmarshalFunc.addLine("    return err")
+    unmarshalFunc.addLine("}")
+    unmarshalFunc.addLine("*" + receiver + " = *parsed")
+    unmarshalFunc.addLine("return nil")
+    source.addFunc(unmarshalFunc)
+
+    decodeFunc := GoFuncs{
+        Name:     "decode",
+        Receiver: &GoFuncArg{Name: receiver, Type: "Cursor", IsPointer: true},
+        Args:     make([]GoFuncArg, 0),
+        Returns:  make([]GoFuncReturn, 0),
+        Lines:    make([]string, 0),
+    }
+    decodeFunc.addArg(GoFuncArg{Name: "key", Type: "any", IsPointer: false})
+    decodeFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    decodeFunc.addLine("err := json.Unmarshal(" + receiver + ".key, key)")
+    decodeFunc.addLine("if err != nil {")
+    decodeFunc.addLine("    return fmt.Errorf(\"invalid cursor: %w\", err)")
+    decodeFunc.addLine("}")
+    decodeFunc.addLine("return nil")
+    source.addFunc(decodeFunc)
+}
+
+// generateSelectPage adds SelectXPage, which returns the rows following a
+// cursor in sort key order, along with the cursor of the next page. Rows are
+// compared by row value, so pages are read off the sort key index rather
+// than skipping OFFSET rows.
+func generateSelectPage(table *metadata.Table, source *GoSourceFile) error {
+    sortKey, err := sortKeyColumns(table)
+    if err != nil {
+        return err
+    }
+    if len(sortKey) == 0 {
+        return nil
+    }
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+    keyType := tableNameCamelCase + "PageKey"
+
+    // the sort key values a cursor carries
+    keyDecl := "// " + keyType + " is the sort key carried by the cursors of " + tableNamePascalCase + " pages\n" +
+        "type " + keyType + " struct {\n"
+    for i := range sortKey {
+        keyDecl += "    " + metadata.ToPascalCase(sortKey[i].Name) + " " + fieldGoType(table, sortKey[i]) + "\n"
+        addFieldImports(table, sortKey[i], source)
+    }
+    source.addDecl(keyDecl + "}")
+
+    pageFunc := GoFuncs{
+        Name:    "Select" + tableNamePascalCase + "Page",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    pageFunc.addArg(ctxArg())
+    pageFunc.addArg(connArg())
+    pageFunc.addArg(GoFuncArg{Name: "after", Type: "Cursor", IsPointer: true})
+    pageFunc.addArg(GoFuncArg{Name: "limit", Type: "uint", IsPointer: false})
+    pageFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
+    pageFunc.addReturn(GoFuncReturn{Type: "Cursor", IsPointer: true})
+    pageFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    columnNames := make([]string, 0)
+    placeholders := make([]string, 0)
+    keyArgs := ""
+    keyFields := ""
+    for i := range sortKey {
+        field := metadata.ToPascalCase(sortKey[i].Name)
+        columnNames = append(columnNames, quotedIdent(sortKey[i].Name))
+        placeholders = append(placeholders, dialect.placeholder(i+1))
+        keyArgs += "key." + field + ", "
+        if i > 0 {
+            keyFields += ", "
+        }
+        keyFields += "last." + field
+    }
+    after := "(" + strings.Join(columnNames, ", ") + ") > (" + strings.Join(placeholders, ", ") + ")"
+    if len(sortKey) == 1 {
+        after = columnNames[0] + " > " + placeholders[0]
+    }
+
+    pageFunc.addLine("query := \"" + selectFrom(table) + orderBy(sortKey) + " LIMIT " + dialect.placeholder(1) + "\"")
+    pageFunc.addLine("args := []any{limit}")
+    pageFunc.addLine("if after != nil {")
+    pageFunc.addLine("    var key " + keyType)
+    pageFunc.addLine("    err := after.decode(&key)")
+    pageFunc.addLine("    if err != nil {")
+    pageFunc.addLine("        return nil, nil, err")
+    pageFunc.addLine("    }")
+    pageFunc.addLine("    query = \"" + selectFrom(table) + " WHERE " + after + orderBy(sortKey) + " LIMIT " + dialect.placeholder(len(sortKey)+1) + "\"")
+    pageFunc.addLine("    args = []any{" + keyArgs + "limit}")
+    pageFunc.addLine("}")
+    pageFunc.addLine("")
+    pageFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, query, args...)")
+    pageFunc.addLine("if err != nil {")
+    pageFunc.addLine("    return nil, nil, fmt.Errorf(\"error selecting page: %w\", err)")
+    pageFunc.addLine("}")
+    pageFunc.addLine("defer rows.Close()")
+    pageFunc.addLine("")
+    pageFunc.addLine("results, err := ScanAll" + tableNamePascalCase + "Rows(&rows)")
+    pageFunc.addLine("if err != nil {")
+    pageFunc.addLine("    return nil, nil, err")
+    pageFunc.addLine("}")
+    // a short page is the last one
+    pageFunc.addLine("if len(results) == 0 || uint(len(results)) < limit {")
+    pageFunc.addLine("    return results, nil, nil")
+    pageFunc.addLine("}")
+    pageFunc.addLine("last := results[len(results)-1]")
+    pageFunc.addLine("next, err := newCursor(" + keyType + "{" + keyFields + "})")
+    pageFunc.addLine("if err != nil {")
+    pageFunc.addLine("    return nil, nil, err")
+    pageFunc.addLine("}")
+    pageFunc.addLine("return results, next, nil")
+
+    source.addFunc(pageFunc)
+    return nil
+}
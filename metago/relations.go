@@ -0,0 +1,358 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+    "strconv"
+    "strings"
+)
+
+// ======================================================================================
+//     Relations
+// ======================================================================================
+
+// foreignKey is a FOREIGN KEY constraint of a table, its columns referencing
+// the columns of a parent table in the same order
+type foreignKey struct {
+    cols       []*metadata.Column
+    parent     *metadata.Table
+    parentCols []*metadata.Column
+    nameSuffix string
+}
+
+// foreignKeys lists the foreign keys of a table whose parent is generated too,
+// composite ones included. Functions of tables referencing a parent through
+// several foreign keys are told apart by the names of the columns.
+func foreignKeys(table *metadata.Table) []foreignKey {
+    fks := make([]foreignKey, 0)
+    if sourceMetadata == nil {
+        return fks
+    }
+    for _, constraint := range table.SearchConstraints(metadata.ForeignKeyConstraint) {
+        parent := sourceMetadata.SearchTable(constraint.RefSchema, constraint.RefTable)
+        if parent == nil || len(constraint.Columns) != len(constraint.RefColumns) {
+            continue
+        }
+        fk := foreignKey{parent: parent}
+        for i := range constraint.Columns {
+            col := table.SearchColumnByName(constraint.Columns[i])
+            parentCol := parent.SearchColumnByName(constraint.RefColumns[i])
+            if col == nil || parentCol == nil {
+                break
+            }
+            fk.cols = append(fk.cols, col)
+            fk.parentCols = append(fk.parentCols, parentCol)
+        }
+        if len(fk.cols) != len(constraint.Columns) {
+            continue
+        }
+        fks = append(fks, fk)
+    }
+    for i := range fks {
+        for j := range fks {
+            if i != j && fks[i].parent == fks[j].parent {
+                columnNames := make([]string, 0)
+                for k := range fks[i].cols {
+                    columnNames = append(columnNames, metadata.ToPascalCase(fks[i].cols[k].Name))
+                }
+                fks[i].nameSuffix = "By" + strings.Join(columnNames, "And")
+            }
+        }
+    }
+    return fks
+}
+
+// keyConditions returns the predicate matching cols to consecutive
+// placeholders
+func keyConditions(cols []*metadata.Column) string {
+    conditions := make([]string, 0)
+    for i := range cols {
+        conditions = append(conditions, quotedIdent(cols[i].Name)+" = "+dialect.placeholder(i+1))
+    }
+    return strings.Join(conditions, " AND ")
+}
+
+// keyArgs adds the checks returning no rows when a key holds NULL, which
+// references nothing, and returns the arguments binding the key
+func keyArgs(f *GoFuncs, cols []*metadata.Column, prefix string) string {
+    args := make([]string, 0)
+    for i := range cols {
+        field := prefix + "." + metadata.ToPascalCase(cols[i].Name)
+        if cols[i].Nullable {
+            f.addLine("if " + field + " == nil {")
+            f.addLine("    return nil, nil")
+            f.addLine("}")
+            field = "*" + field
+        }
+        args = append(args, field)
+    }
+    return strings.Join(args, ", ")
+}
+
+// generateRelations adds, for every foreign key of a table, GetXParentY which
+// selects the parent row of a row, ListXChildrenOfY which selects the rows
+// referencing a parent, and LoadXChildrenOfY which selects the children of
+// several parents at once, grouped by the referenced value
+func generateRelations(table *metadata.Table, source *GoSourceFile) error {
+    fks := foreignKeys(table)
+    for i := range fks {
+        generateGetParent(table, &fks[i], source)
+        generateListChildren(table, &fks[i], source)
+        generateLoadChildren(table, &fks[i], source)
+    }
+    return nil
+}
+
+func generateGetParent(table *metadata.Table, fk *foreignKey, source *GoSourceFile) {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    tableNameCamelCase := metadata.ToCamelCase(baseName(table))
+    parentPascalCase := metadata.ToPascalCase(baseName(fk.parent))
+
+    getFunc := GoFuncs{
+        Name:    "Get" + tableNamePascalCase + "Parent" + parentPascalCase + fk.nameSuffix,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    getFunc.addArg(ctxArg())
+    getFunc.addArg(connArg())
+    getFunc.addArg(GoFuncArg{Name: tableNameCamelCase, Type: tableNamePascalCase, IsPointer: true})
+    getFunc.addReturn(GoFuncReturn{Type: parentPascalCase, IsPointer: true})
+    getFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    // a NULL reference has no parent
+    args := keyArgs(&getFunc, fk.cols, tableNameCamelCase)
+    sql := selectFrom(fk.parent) + " WHERE " + keyConditions(fk.parentCols)
+    getFunc.addLine("row := conn." + dialect.QueryRowFunc + "(ctx, \"" + sql + "\", " + args + ")")
+    getFunc.addLine("return ScanSingle" + parentPascalCase + "Row(&row)")
+
+    source.addFunc(getFunc)
+}
+
+func generateListChildren(table *metadata.Table, fk *foreignKey, source *GoSourceFile) {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    parentPascalCase := metadata.ToPascalCase(baseName(fk.parent))
+    parentCamelCase := metadata.ToCamelCase(baseName(fk.parent))
+    if parentCamelCase == metadata.ToCamelCase(baseName(table)) {
+        // rows of a table referencing the same table
+        parentCamelCase = "parent"
+    }
+
+    listFunc := GoFuncs{
+        Name:    "List" + tableNamePascalCase + "ChildrenOf" + parentPascalCase + fk.nameSuffix,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    listFunc.addArg(ctxArg())
+    listFunc.addArg(connArg())
+    listFunc.addArg(GoFuncArg{Name: parentCamelCase, Type: parentPascalCase, IsPointer: true})
+    listFunc.addReturn(GoFuncReturn{Type: "[]" + tableNamePascalCase, IsPointer: false})
+    listFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    args := keyArgs(&listFunc, fk.parentCols, parentCamelCase)
+    sql := selectFrom(table) + " WHERE " + keyConditions(fk.cols) + orderBy(primaryKeyColumns(table))
+    listFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, \"" + sql + "\", " + args + ")")
+    addIfErr(&listFunc, "error selecting children: %w", 0)
+    listFunc.addLine("defer rows.Close()")
+    listFunc.addLine("")
+    listFunc.addLine("return ScanAll" + tableNamePascalCase + "Rows(&rows)")
+
+    source.addFunc(listFunc)
+}
+
+// generateLoadChildren adds the eager loader of a foreign key, which selects
+// the children of a slice of parents in a single query. Values that can't be
+// map keys have no loader.
+func generateLoadChildren(table *metadata.Table, fk *foreignKey, source *GoSourceFile) {
+    for i := range fk.cols {
+        keyType := fieldGoType(table, fk.cols[i])
+        if strings.HasPrefix(keyType, "[]") || strings.HasPrefix(keyType, "map[") || isJSONColumn(fk.cols[i]) {
+            return
+        }
+    }
+    if len(fk.cols) > 1 {
+        generateLoadCompositeChildren(table, fk, source)
+        return
+    }
+    keyType := fieldGoType(table, fk.cols[0])
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    parentPascalCase := metadata.ToPascalCase(baseName(fk.parent))
+    parentField := "parents[i]." + metadata.ToPascalCase(fk.parentCols[0].Name)
+    childField := "loaded[i]." + metadata.ToPascalCase(fk.cols[0].Name)
+
+    loadFunc := GoFuncs{
+        Name:    "Load" + tableNamePascalCase + "ChildrenOf" + parentPascalCase + fk.nameSuffix,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    loadFunc.addArg(ctxArg())
+    loadFunc.addArg(connArg())
+    loadFunc.addArg(GoFuncArg{Name: "parents", Type: "[]" + parentPascalCase, IsPointer: false})
+    loadFunc.addReturn(GoFuncReturn{Type: "map[" + keyType + "][]" + tableNamePascalCase, IsPointer: false})
+    loadFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    loadFunc.addLine("keys := make([]" + keyType + ", 0, len(parents))")
+    loadFunc.addLine("for i := range parents {")
+    if fk.parentCols[0].Nullable {
+        loadFunc.addLine("    if " + parentField + " != nil {")
+        loadFunc.addLine("        keys = append(keys, " + keyType + "(*" + parentField + "))")
+        loadFunc.addLine("    }")
+    } else {
+        loadFunc.addLine("    keys = append(keys, " + keyType + "(" + parentField + "))")
+    }
+    loadFunc.addLine("}")
+    loadFunc.addLine("children := make(map[" + keyType + "][]" + tableNamePascalCase + ")")
+    loadFunc.addLine("if len(keys) == 0 {")
+    loadFunc.addLine("    return children, nil")
+    loadFunc.addLine("}")
+    loadFunc.addLine("")
+
+    // PostgreSQL binds the keys as an array, other DBMS get a placeholder per key
+    if dialect.isPostgres() {
+        sql := selectFrom(table) + " WHERE " + quotedIdent(fk.cols[0].Name) + " = ANY($1)" + orderBy(primaryKeyColumns(table))
+        loadFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, \"" + sql + "\", keys)")
+    } else {
+        if !metadata.ContainsString(source.Imports, "strings") {
+            source.addImport("strings")
+        }
+        loadFunc.addLine("args := make([]any, 0, len(keys))")
+        loadFunc.addLine("for i := range keys {")
+        loadFunc.addLine("    args = append(args, keys[i])")
+        loadFunc.addLine("}")
+        loadFunc.addLine("placeholders := strings.TrimSuffix(strings.Repeat(\"?, \", len(keys)), \", \")")
+        loadFunc.addLine("query := \"" + selectFrom(table) + " WHERE " + quotedIdent(fk.cols[0].Name) + " IN (\" + placeholders + \")" + orderBy(primaryKeyColumns(table)) + "\"")
+        loadFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, query, args...)")
+    }
+    addIfErr(&loadFunc, "error selecting children: %w", 0)
+    loadFunc.addLine("defer rows.Close()")
+    loadFunc.addLine("")
+    loadFunc.addLine("loaded, err := ScanAll" + tableNamePascalCase + "Rows(&rows)")
+    loadFunc.addLine("if err != nil {")
+    loadFunc.addLine("    return nil, err")
+    loadFunc.addLine("}")
+    loadFunc.addLine("for i := range loaded {")
+    if fk.cols[0].Nullable {
+        loadFunc.addLine("    key := *" + childField)
+    } else {
+        loadFunc.addLine("    key := " + childField)
+    }
+    loadFunc.addLine("    children[key] = append(children[key], loaded[i])")
+    loadFunc.addLine("}")
+    loadFunc.addLine("return children, nil")
+
+    source.addFunc(loadFunc)
+}
+
+// generateLoadCompositeChildren adds the eager loader of a composite foreign
+// key, which groups the children by a struct of the referencing values. The
+// keys are matched as row values, bound a placeholder per column.
+func generateLoadCompositeChildren(table *metadata.Table, fk *foreignKey, source *GoSourceFile) {
+    tableNamePascalCase := metadata.ToPascalCase(baseName(table))
+    parentPascalCase := metadata.ToPascalCase(baseName(fk.parent))
+    loadName := "Load" + tableNamePascalCase + "ChildrenOf" + parentPascalCase + fk.nameSuffix
+    keyType := tableNamePascalCase + "ChildrenOf" + parentPascalCase + fk.nameSuffix + "Key"
+
+    key := GoStruct{
+        Name:   keyType,
+        Doc:    keyType + " holds the referencing values " + loadName + " groups children by",
+        Fields: make([]GoStructField, 0),
+    }
+    for i := range fk.cols {
+        key.addField(GoStructField{
+            Name: metadata.ToPascalCase(fk.cols[i].Name),
+            Type: fieldGoType(table, fk.cols[i]),
+        })
+    }
+    source.addStruct(key)
+
+    loadFunc := GoFuncs{
+        Name:    loadName,
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    loadFunc.addArg(ctxArg())
+    loadFunc.addArg(connArg())
+    loadFunc.addArg(GoFuncArg{Name: "parents", Type: "[]" + parentPascalCase, IsPointer: false})
+    loadFunc.addReturn(GoFuncReturn{Type: "map[" + keyType + "][]" + tableNamePascalCase, IsPointer: false})
+    loadFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+
+    nullChecks := make([]string, 0)
+    parentValues := make([]string, 0)
+    childValues := make([]string, 0)
+    keyFields := make([]string, 0)
+    columnNames := make([]string, 0)
+    for i := range fk.cols {
+        fieldName := metadata.ToPascalCase(fk.cols[i].Name)
+        colType := fieldGoType(table, fk.cols[i])
+        parentField := "parents[i]." + metadata.ToPascalCase(fk.parentCols[i].Name)
+        if fk.parentCols[i].Nullable {
+            nullChecks = append(nullChecks, parentField+" == nil")
+            parentField = "*" + parentField
+        }
+        parentValues = append(parentValues, fieldName+": "+colType+"("+parentField+")")
+        childField := "loaded[i]." + fieldName
+        if fk.cols[i].Nullable {
+            childField = "*" + childField
+        }
+        childValues = append(childValues, fieldName+": "+childField)
+        keyFields = append(keyFields, "keys[i]."+fieldName)
+        columnNames = append(columnNames, quotedIdent(fk.cols[i].Name))
+    }
+
+    loadFunc.addLine("keys := make([]" + keyType + ", 0, len(parents))")
+    loadFunc.addLine("for i := range parents {")
+    if len(nullChecks) > 0 {
+        loadFunc.addLine("    if " + strings.Join(nullChecks, " || ") + " {")
+        loadFunc.addLine("        continue")
+        loadFunc.addLine("    }")
+    }
+    loadFunc.addLine("    keys = append(keys, " + keyType + "{" + strings.Join(parentValues, ", ") + "})")
+    loadFunc.addLine("}")
+    loadFunc.addLine("children := make(map[" + keyType + "][]" + tableNamePascalCase + ")")
+    loadFunc.addLine("if len(keys) == 0 {")
+    loadFunc.addLine("    return children, nil")
+    loadFunc.addLine("}")
+    loadFunc.addLine("")
+
+    if !metadata.ContainsString(source.Imports, "strings") {
+        source.addImport("strings")
+    }
+    loadFunc.addLine("args := make([]any, 0, len(keys)*" + strconv.Itoa(len(fk.cols)) + ")")
+    loadFunc.addLine("placeholders := make([]string, 0, len(keys))")
+    loadFunc.addLine("for i := range keys {")
+    loadFunc.addLine("    args = append(args, " + strings.Join(keyFields, ", ") + ")")
+    if dialect.isPostgres() {
+        formats := make([]string, 0)
+        positions := make([]string, 0)
+        for i := range fk.cols {
+            formats = append(formats, "$%d")
+            if offset := len(fk.cols) - 1 - i; offset > 0 {
+                positions = append(positions, "len(args)-"+strconv.Itoa(offset))
+            } else {
+                positions = append(positions, "len(args)")
+            }
+        }
+        loadFunc.addLine("    placeholders = append(placeholders, fmt.Sprintf(\"(" + strings.Join(formats, ", ") + ")\", " + strings.Join(positions, ", ") + "))")
+    } else {
+        loadFunc.addLine("    placeholders = append(placeholders, \"(" + strings.TrimSuffix(strings.Repeat("?, ", len(fk.cols)), ", ") + ")\")")
+    }
+    loadFunc.addLine("}")
+    loadFunc.addLine("query := \"" + selectFrom(table) + " WHERE (" + strings.Join(columnNames, ", ") + ") IN (\" + strings.Join(placeholders, \", \") + \")" + orderBy(primaryKeyColumns(table)) + "\"")
+    loadFunc.addLine("rows, err := conn." + dialect.QueryFunc + "(ctx, query, args...)")
+    addIfErr(&loadFunc, "error selecting children: %w", 0)
+    loadFunc.addLine("defer rows.Close()")
+    loadFunc.addLine("")
+    loadFunc.addLine("loaded, err := ScanAll" + tableNamePascalCase + "Rows(&rows)")
+    loadFunc.addLine("if err != nil {")
+    loadFunc.addLine("    return nil, err")
+    loadFunc.addLine("}")
+    loadFunc.addLine("for i := range loaded {")
+    loadFunc.addLine("    key := " + keyType + "{" + strings.Join(childValues, ", ") + "}")
+    loadFunc.addLine("    children[key] = append(children[key], loaded[i])")
+    loadFunc.addLine("}")
+    loadFunc.addLine("return children, nil")
+
+    source.addFunc(loadFunc)
+}
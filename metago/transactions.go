@@ -0,0 +1,120 @@
+package metago
+
+import (
+    "dto-gen/metadata"
+)
+
+// ======================================================================================
+//     Transactions
+// ======================================================================================
+
+// generateTxHelpers adds WithTx to the connector, which runs a function in a
+// transaction and retries it on serialization failures and deadlocks
+func generateTxHelpers(source *GoSourceFile) {
+    txType := "*sql.Tx"
+    txOptionsType := "*sql.TxOptions"
+    txCtx := ""
+    if dialect.isPostgres() {
+        txType = "pgx.Tx"
+        txOptionsType = "pgx.TxOptions"
+        txCtx = "ctx"
+    }
+    if dialect.DBMS != "SQLite" && !metadata.ContainsString(source.Imports, "errors") {
+        source.addImport("errors")
+    }
+
+    source.addDecl("// TxBeginner is implemented by the connections and pools WithTx can start transactions on\n" +
+        "type TxBeginner interface {\n" +
+        "    BeginTx(ctx context.Context, opts " + txOptionsType + ") (" + txType + ", error)\n" +
+        "}")
+    source.addDecl("// TxOptions tunes WithTx, MaxRetries being how many more times a transaction\n" +
+        "// failing on a serialization failure or a deadlock is run\n" +
+        "type TxOptions struct {\n" +
+        "    Options    " + txOptionsType + "\n" +
+        "    MaxRetries int\n" +
+        "}")
+
+    // one attempt, rolled back on error or panic
+    runTxFunc := GoFuncs{
+        Name:    "runTx",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    runTxFunc.addArg(ctxArg())
+    runTxFunc.addArg(GoFuncArg{Name: "db", Type: "TxBeginner", IsPointer: false})
+    runTxFunc.addArg(GoFuncArg{Name: "opts", Type: "TxOptions", IsPointer: true})
+    runTxFunc.addArg(GoFuncArg{Name: "fn", Type: "func(tx DBTX) error", IsPointer: false})
+    runTxFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    runTxFunc.addLine("tx, err := db.BeginTx(ctx, opts.Options)")
+    runTxFunc.addLine("if err != nil {")
+    runTxFunc.addLine("    return fmt.Errorf(\"error beginning transaction: %w\", err)")
+    runTxFunc.addLine("}")
+    runTxFunc.addLine("defer func() {")
+    runTxFunc.addLine("    if p := recover(); p != nil {")
+    runTxFunc.addLine("        tx.Rollback(" + txCtx + ")")
+    runTxFunc.addLine("        panic(p)")
+    runTxFunc.addLine("    }")
+    runTxFunc.addLine("}()")
+    runTxFunc.addLine("err = fn(tx)")
+    runTxFunc.addLine("if err != nil {")
+    runTxFunc.addLine("    tx.Rollback(" + txCtx + ")")
+    runTxFunc.addLine("    return err")
+    runTxFunc.addLine("}")
+    runTxFunc.addLine("err = tx.Commit(" + txCtx + ")")
+    runTxFunc.addLine("if err != nil {")
+    runTxFunc.addLine("    return fmt.Errorf(\"error committing transaction: %w\", err)")
+    runTxFunc.addLine("}")
+    runTxFunc.addLine("return nil")
+    source.addFunc(runTxFunc)
+
+    // SQLSTATE 40001 (serialization failure) and 40P01 (deadlock detected)
+    retryableFunc := GoFuncs{
+        Name:    "isRetryableTxError",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    retryableFunc.addArg(GoFuncArg{Name: "err", Type: "error", IsPointer: false})
+    retryableFunc.addReturn(GoFuncReturn{Type: "bool", IsPointer: false})
+    if dialect.isPostgres() {
+        retryableFunc.addLine("var pgErr *pgconn.PgError")
+        retryableFunc.addLine("if errors.As(err, &pgErr) {")
+        retryableFunc.addLine("    return pgErr.Code == \"40001\" || pgErr.Code == \"40P01\"")
+        retryableFunc.addLine("}")
+        retryableFunc.addLine("return false")
+    } else if dialect.DBMS == "MySQL" {
+        retryableFunc.addLine("var myErr *mysql.MySQLError")
+        retryableFunc.addLine("if errors.As(err, &myErr) {")
+        retryableFunc.addLine("    sqlState := string(myErr.SQLState[:])")
+        retryableFunc.addLine("    return sqlState == \"40001\" || sqlState == \"40P01\"")
+        retryableFunc.addLine("}")
+        retryableFunc.addLine("return false")
+    } else {
+        // sqlite reports no SQLSTATE, and serializes writers anyway
+        retryableFunc.addLine("return false")
+    }
+    source.addFunc(retryableFunc)
+
+    withTxFunc := GoFuncs{
+        Name:    "WithTx",
+        Args:    make([]GoFuncArg, 0),
+        Returns: make([]GoFuncReturn, 0),
+        Lines:   make([]string, 0),
+    }
+    withTxFunc.addArg(ctxArg())
+    withTxFunc.addArg(GoFuncArg{Name: "db", Type: "TxBeginner", IsPointer: false})
+    withTxFunc.addArg(GoFuncArg{Name: "opts", Type: "TxOptions", IsPointer: true})
+    withTxFunc.addArg(GoFuncArg{Name: "fn", Type: "func(tx DBTX) error", IsPointer: false})
+    withTxFunc.addReturn(GoFuncReturn{Type: "error", IsPointer: false})
+    withTxFunc.addLine("if opts == nil {")
+    withTxFunc.addLine("    opts = &TxOptions{}")
+    withTxFunc.addLine("}")
+    withTxFunc.addLine("for attempt := 0; ; attempt++ {")
+    withTxFunc.addLine("    err := runTx(ctx, db, opts, fn)")
+    withTxFunc.addLine("    if err == nil || attempt >= opts.MaxRetries || !isRetryableTxError(err) {")
+    withTxFunc.addLine("        return err")
+    withTxFunc.addLine("    }")
+    withTxFunc.addLine("}")
+    source.addFunc(withTxFunc)
+}
@@ -3,10 +3,14 @@ package metapy
 import (
 	"dto-gen/config"
 	"dto-gen/metadata"
+	"dto-gen/mysql"
 	"dto-gen/pgsql"
+	"dto-gen/sqlite"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode"
 )
 
 // ======================================================================================
@@ -24,6 +28,16 @@ func (s *PythonSourceFile) addImport(impt PythonImport) {
 	s.Imports = append(s.Imports, impt)
 }
 
+// hasImport tells whether the source already has the exact same import
+func (s *PythonSourceFile) hasImport(impt PythonImport) bool {
+	for i := range s.Imports {
+		if s.Imports[i].toString() == impt.toString() {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *PythonSourceFile) addClass(cls PythonClass) {
 	s.Classes = append(s.Classes, cls)
 }
@@ -54,18 +68,28 @@ func (i *PythonImport) toString() string {
 
 type PythonClass struct {
 	Name       string
+	Base       string
 	Annotation *string
+	Doc        string
 	Fields     []PythonDataClassField
+	Values     []PythonClassValue
 }
 
 func (c *PythonClass) addField(f PythonDataClassField) {
 	c.Fields = append(c.Fields, f)
 }
 
+// PythonClassValue is a class attribute assigned a literal, as enum members are
+type PythonClassValue struct {
+	Name  string
+	Value string
+}
+
 type PythonDataClassField struct {
 	Name       string
 	Type       string
 	IsOptional bool
+	Doc        string
 }
 
 type PythonFunc struct {
@@ -118,6 +142,29 @@ func (p *PythonParameter) toString() string {
 	return t
 }
 
+// docstring quotes a text as a docstring at the given indentation, escaping
+// what would end it early
+func docstring(text string, indent string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\\", "\\\\")
+	text = strings.ReplaceAll(text, "\"\"\"", "\\\"\\\"\\\"")
+	if strings.HasSuffix(text, "\"") {
+		text = strings.TrimSuffix(text, "\"") + "\\\""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return indent + "\"\"\"" + lines[0] + "\"\"\"\n"
+	}
+	doc := indent + "\"\"\"" + lines[0] + "\n"
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			line = indent + line
+		}
+		doc += line + "\n"
+	}
+	return doc + indent + "\"\"\"\n"
+}
+
 func writePythonSource(folder string, source PythonSourceFile) error {
 	// create file
 	text := ""
@@ -131,10 +178,24 @@ func writePythonSource(folder string, source PythonSourceFile) error {
 	// write classes
 	for i := range source.Classes {
 		class := source.Classes[i]
+		if i > 0 {
+			text += "\n\n"
+		}
 		if class.Annotation != nil {
 			text += "@" + *class.Annotation + "\n"
 		}
-		text += "class " + class.Name + ":\n"
+		text += "class " + class.Name
+		if class.Base != "" {
+			text += "(" + class.Base + ")"
+		}
+		text += ":\n"
+		if class.Doc != "" {
+			text += docstring(class.Doc, "    ")
+		}
+
+		for j := range class.Values {
+			text += "    " + class.Values[j].Name + " = " + class.Values[j].Value + "\n"
+		}
 
 		for j := range class.Fields {
 			field := class.Fields[j]
@@ -144,6 +205,10 @@ func writePythonSource(folder string, source PythonSourceFile) error {
 			} else {
 				text += field.Type + "\n"
 			}
+			// attribute docstrings, as documentation tools read them
+			if field.Doc != "" {
+				text += docstring(field.Doc, "    ")
+			}
 		}
 	}
 	text += "\n\n"
@@ -176,6 +241,184 @@ func writePythonSource(folder string, source PythonSourceFile) error {
 //     DTO Generation
 // ======================================================================================
 
+// PythonDialect describes the python driver and type map used for a given DBMS
+type PythonDialect struct {
+	DBMS          string
+	Driver        string
+	DriverImports []PythonImport
+	DatabaseKey   string
+	Types         map[string]string
+}
+
+var postgresDialect = PythonDialect{
+	DBMS:   "PostgreSQL",
+	Driver: "psycopg2",
+	DriverImports: []PythonImport{
+		{Library: "psycopg2", Classes: []string{}},
+		{Library: "psycopg2.extensions", Classes: []string{"connection"}},
+	},
+	DatabaseKey: "dbname",
+	Types:       pgsql.PostgreSQLToPythonTypes,
+}
+
+var mysqlDialect = PythonDialect{
+	DBMS:   "MySQL",
+	Driver: "pymysql",
+	DriverImports: []PythonImport{
+		{Library: "pymysql", Classes: []string{}},
+		{Library: "pymysql.connections", Classes: []string{"Connection as connection"}},
+	},
+	DatabaseKey: "database",
+	Types:       mysql.MySQLToPythonTypes,
+}
+
+var sqliteDialect = PythonDialect{
+	DBMS:   "SQLite",
+	Driver: "sqlite3",
+	DriverImports: []PythonImport{
+		{Library: "sqlite3", Classes: []string{}},
+		{Library: "sqlite3", Classes: []string{"Connection as connection"}},
+	},
+	DatabaseKey: "database",
+	Types:       sqlite.SQLiteToPythonTypes,
+}
+
+// dialect used by the generators, selected by WritePython
+var dialect = &postgresDialect
+
+func dialectFor(dbms string) (*PythonDialect, error) {
+	switch dbms {
+	case "PostgreSQL":
+		return &postgresDialect, nil
+	case "MySQL", "MariaDB":
+		return &mysqlDialect, nil
+	case "SQLite":
+		return &sqliteDialect, nil
+	}
+	return nil, fmt.Errorf("unsupported DBMS for python generation: %s", dbms)
+}
+
+// metadata being generated, so column types can refer to its enums
+var sourceMetadata *metadata.Metadata
+
+// config being generated, so types can be overridden
+var sourceConfig *config.Config
+
+// typeNameOf is the database type a column is mapped by, that of its
+// elements for arrays. PostgreSQL types are mapped by udt name.
+func (d *PythonDialect) typeNameOf(col *metadata.Column) string {
+	if d.DBMS == "PostgreSQL" {
+		return pgsql.ColumnUdtName(col)
+	}
+	if col.IsArray() && col.ElementType != "" {
+		return col.ElementType
+	}
+	return col.Datatype
+}
+
+// pythonTypeOf maps a column to its type hint, enum columns getting the
+// class generated for their enum and array columns a list of their elements.
+// Types we don't know about are hinted as Any.
+func (d *PythonDialect) pythonTypeOf(meta *metadata.Metadata, col *metadata.Column) string {
+	isArray := col.IsArray() && col.ElementType != ""
+	pythonType, exists := d.Types[d.typeNameOf(col)]
+	if !exists {
+		pythonType = "Any"
+	}
+	if meta != nil {
+		if enum := meta.ColumnEnum(col); enum != nil {
+			pythonType = meta.EnumTypeName(enum)
+		}
+	}
+	if isArray {
+		for i := 0; i < max(col.ArrayDims, 1); i++ {
+			pythonType = "list[" + pythonType + "]"
+		}
+	}
+	return pythonType
+}
+
+// pythonTypeOverride returns the override replacing the type hint of a
+// database type, nil if the config has none. PostgreSQL types can be given
+// by udt or display name.
+func pythonTypeOverride(datatype string) *config.TypeOverride {
+	if sourceConfig == nil {
+		return nil
+	}
+	names := []string{datatype}
+	if dialect.DBMS == "PostgreSQL" {
+		if pgType := pgsql.SearchPgType(datatype); pgType != nil {
+			names = append(names, pgType.UdtName, pgType.DisplayName)
+		}
+	}
+	override := sourceConfig.SearchTypeOverride(names...)
+	if override == nil || override.PythonType == "" {
+		return nil
+	}
+	return override
+}
+
+// pythonColumnOverride returns the override replacing the type hint of a
+// column, nil if the config has none
+func pythonColumnOverride(table *metadata.Table, col *metadata.Column) *config.TypeOverride {
+	if sourceConfig == nil {
+		return nil
+	}
+	override := sourceConfig.SearchColumnOverride(table.Schema, table.Name, col.Name)
+	if override == nil || override.PythonType == "" {
+		return nil
+	}
+	return override
+}
+
+// fieldPythonType is the type hint of the dataclass field of a column,
+// taking overrides into account
+func fieldPythonType(table *metadata.Table, col *metadata.Column) string {
+	if override := pythonColumnOverride(table, col); override != nil {
+		return override.PythonType
+	}
+	override := pythonTypeOverride(dialect.typeNameOf(col))
+	if override == nil {
+		return dialect.pythonTypeOf(sourceMetadata, col)
+	}
+	pythonType := override.PythonType
+	if col.IsArray() && col.ElementType != "" {
+		for i := 0; i < max(col.ArrayDims, 1); i++ {
+			pythonType = "list[" + pythonType + "]"
+		}
+	}
+	return pythonType
+}
+
+// overrideImport is the import of the type hint of an override, the module
+// itself when the type is qualified by it
+func overrideImport(override *config.TypeOverride) PythonImport {
+	if strings.HasPrefix(override.PythonType, override.PythonImport+".") {
+		return PythonImport{Library: override.PythonImport, Classes: []string{}}
+	}
+	return PythonImport{Library: override.PythonImport, Classes: []string{override.PythonType}}
+}
+
+// PythonFieldType returns the type hint of the dataclass field generated for
+// a column, overridden as the config tells
+func PythonFieldType(dbms string, meta *metadata.Metadata, config *config.Config, table *metadata.Table, col *metadata.Column) (string, error) {
+	d, err := dialectFor(dbms)
+	if err != nil {
+		return "", err
+	}
+	previousDialect, previousMetadata, previousConfig := dialect, sourceMetadata, sourceConfig
+	dialect, sourceMetadata, sourceConfig = d, meta, config
+	defer func() {
+		dialect, sourceMetadata, sourceConfig = previousDialect, previousMetadata, previousConfig
+	}()
+
+	pythonType := fieldPythonType(table, col)
+	if col.Nullable {
+		return "Optional[" + pythonType + "]", nil
+	}
+	return pythonType, nil
+}
+
 func removeExistingPythonFiles(folder string) error {
 	fmt.Println("Removing old DTO files...")
 	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
@@ -222,9 +465,10 @@ func generatePythonDbConnector(connInfo *config.ConnectionInfo, folder string) e
 		Funcs:   make([]PythonFunc, 0),
 	}
 
-	// add imports needed to talk to postgresql
-	pythonSource.addImport(PythonImport{Library: "psycopg2", Classes: []string{}})
-	pythonSource.addImport(PythonImport{Library: "psycopg2.extensions", Classes: []string{"connection"}})
+	// add imports needed to talk to the database
+	for i := range dialect.DriverImports {
+		pythonSource.addImport(dialect.DriverImports[i])
+	}
 	pythonSource.addImport(PythonImport{Library: "typing", Classes: []string{"Dict", "Union"}})
 
 	// add connect function
@@ -241,14 +485,17 @@ func generatePythonDbConnector(connInfo *config.ConnectionInfo, folder string) e
 	})
 	connectFunc.addStatement("if db_config is None:")
 	connectFunc.addStatement("    db_config = {")
-	connectFunc.addStatement("        'dbname': '" + connInfo.Database + "',")
-	connectFunc.addStatement("        'user': '" + connInfo.Username + "',")
-	connectFunc.addStatement("        'password': '" + connInfo.Password + "',")
-	connectFunc.addStatement("        'host': '" + connInfo.Host + "',")
-	connectFunc.addStatement(fmt.Sprintf("        'port': %d,", connInfo.Port))
+	connectFunc.addStatement("        '" + dialect.DatabaseKey + "': '" + connInfo.Database + "',")
+	if dialect.DBMS != "SQLite" {
+		// sqlite databases are local files, with no server to log into
+		connectFunc.addStatement("        'user': '" + connInfo.Username + "',")
+		connectFunc.addStatement("        'password': '" + connInfo.Password + "',")
+		connectFunc.addStatement("        'host': '" + connInfo.Host + "',")
+		connectFunc.addStatement(fmt.Sprintf("        'port': %d,", connInfo.Port))
+	}
 	connectFunc.addStatement("    }\n")
 	connectFunc.addStatement("try:")
-	connectFunc.addStatement("    conn = psycopg2.connect(**db_config)")
+	connectFunc.addStatement("    conn = " + dialect.Driver + ".connect(**db_config)")
 	connectFunc.addStatement("    return conn")
 	connectFunc.addStatement("except Exception as e:")
 	connectFunc.addStatement("    print(f\"Error connecting to the database: {e}\")")
@@ -266,8 +513,9 @@ func generatePythonDbConnector(connInfo *config.ConnectionInfo, folder string) e
 func generatePythonTableDataclass(table *metadata.Table, source *PythonSourceFile) error {
 	dataClassAnnotation := "dataclass"
 	entity := PythonClass{
-		Name:       metadata.ToPascalCase(table.Name),
+		Name:       metadata.ToPascalCase(sourceMetadata.TableBaseName(table)),
 		Annotation: &dataClassAnnotation,
+		Doc:        table.Comment,
 		Fields:     make([]PythonDataClassField, 0),
 	}
 
@@ -275,8 +523,9 @@ func generatePythonTableDataclass(table *metadata.Table, source *PythonSourceFil
 		col := table.Columns[i]
 		entity.Fields = append(entity.Fields, PythonDataClassField{
 			Name:       col.Name,
-			Type:       pgsql.PostgreSQLToPythonTypes[col.Datatype],
+			Type:       fieldPythonType(table, &col),
 			IsOptional: col.Nullable,
+			Doc:        col.Comment,
 		})
 	}
 
@@ -284,22 +533,119 @@ func generatePythonTableDataclass(table *metadata.Table, source *PythonSourceFil
 	return nil
 }
 
+// enumMemberName turns an enum label into a python member name, as in
+// "in-progress" -> "IN_PROGRESS"
+func enumMemberName(label string) string {
+	name := ""
+	for _, r := range strings.ToUpper(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			name += string(r)
+		} else if !strings.HasSuffix(name, "_") {
+			name += "_"
+		}
+	}
+	name = strings.Trim(name, "_")
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	return name
+}
+
+func generatePythonEnums(folder string, meta *metadata.Metadata) error {
+	if len(meta.Enums) == 0 {
+		return nil
+	}
+	fmt.Println("    generating enums...")
+
+	pythonSource := PythonSourceFile{
+		Name:    "enums",
+		Imports: make([]PythonImport, 0),
+		Classes: make([]PythonClass, 0),
+		Funcs:   make([]PythonFunc, 0),
+	}
+	pythonSource.addImport(PythonImport{Library: "enum", Classes: []string{}})
+
+	for i := range meta.Enums {
+		enum := &meta.Enums[i]
+		class := PythonClass{
+			Name:   meta.EnumTypeName(enum),
+			Base:   "enum.Enum",
+			Values: make([]PythonClassValue, 0),
+		}
+		names := make([]string, 0)
+		for j := range enum.Labels {
+			name := enumMemberName(enum.Labels[j])
+			if name == "" || metadata.ContainsString(names, name) {
+				// labels made only of symbols, or colliding once cleaned up
+				name = fmt.Sprintf("VALUE_%d", j+1)
+			}
+			names = append(names, name)
+			class.Values = append(class.Values, PythonClassValue{
+				Name:  name,
+				Value: "'" + strings.ReplaceAll(strings.ReplaceAll(enum.Labels[j], "\\", "\\\\"), "'", "\\'") + "'",
+			})
+		}
+		pythonSource.addClass(class)
+	}
+
+	return writePythonSource(folder, pythonSource)
+}
+
 func generatePythonDTO(folder string, table *metadata.Table) error {
 	fmt.Printf("    generating DTO for %s ...\n", table.Name)
 
+	// tables of several schemas may go by the same name
 	pythonSource := PythonSourceFile{
-		Name:    table.Name,
+		Name:    sourceMetadata.TableBaseName(table),
 		Imports: make([]PythonImport, 0),
 		Funcs:   make([]PythonFunc, 0),
 	}
 
-	// add imports needed to talk to postgresql
-	pythonSource.addImport(PythonImport{Library: "psycopg2", Classes: []string{}})
-	pythonSource.addImport(PythonImport{Library: "psycopg2", Classes: []string{"sql"}})
-	pythonSource.addImport(PythonImport{Library: "psycopg2.extensions", Classes: []string{"connection"}})
-	pythonSource.addImport(PythonImport{Library: "typing", Classes: []string{"Dict", "Union", "Optional"}})
+	// add imports needed to talk to the database
+	for i := range dialect.DriverImports {
+		pythonSource.addImport(dialect.DriverImports[i])
+	}
+	if dialect.Driver == "psycopg2" {
+		pythonSource.addImport(PythonImport{Library: "psycopg2", Classes: []string{"sql"}})
+	}
+	pythonSource.addImport(PythonImport{Library: "typing", Classes: []string{"Any", "Dict", "Union", "Optional"}})
 	pythonSource.addImport(PythonImport{Library: "dataclasses", Classes: []string{"dataclass"}})
 	pythonSource.addImport(PythonImport{Library: "datetime", Classes: []string{}})
+	for i := range table.Columns {
+		if strings.Contains(fieldPythonType(table, &table.Columns[i]), "decimal.") {
+			pythonSource.addImport(PythonImport{Library: "decimal", Classes: []string{}})
+			break
+		}
+	}
+	for i := range table.Columns {
+		if strings.Contains(fieldPythonType(table, &table.Columns[i]), "psycopg2.extras.") {
+			pythonSource.addImport(PythonImport{Library: "psycopg2.extras", Classes: []string{}})
+			break
+		}
+	}
+	for i := range table.Columns {
+		override := pythonColumnOverride(table, &table.Columns[i])
+		if override == nil {
+			override = pythonTypeOverride(dialect.typeNameOf(&table.Columns[i]))
+		}
+		if override == nil || override.PythonImport == "" {
+			continue
+		}
+		overrideImp := overrideImport(override)
+		if !pythonSource.hasImport(overrideImp) {
+			pythonSource.addImport(overrideImp)
+		}
+	}
+	enumClasses := make([]string, 0)
+	for i := range table.Columns {
+		enum := sourceMetadata.ColumnEnum(&table.Columns[i])
+		if enum != nil && !metadata.ContainsString(enumClasses, sourceMetadata.EnumTypeName(enum)) {
+			enumClasses = append(enumClasses, sourceMetadata.EnumTypeName(enum))
+		}
+	}
+	if len(enumClasses) > 0 {
+		pythonSource.addImport(PythonImport{Library: ".enums", Classes: enumClasses})
+	}
 
 	// generate table dataclass used throughout the file
 	err := generatePythonTableDataclass(table, &pythonSource)
@@ -315,11 +661,20 @@ func generatePythonDTO(folder string, table *metadata.Table) error {
 	return nil
 }
 
-func WritePython(connInfo *config.ConnectionInfo, folder string, metadata *metadata.Metadata, customQueries []config.CustomQuery) error {
+func WritePython(config *config.Config, folder string, metadata *metadata.Metadata, customQueries []config.CustomQuery) error {
 	fmt.Println("Generating DTO files on " + folder)
 
+	// pick the type map and driver of the target DBMS
+	var err error
+	dialect, err = dialectFor(config.ConnInfo.DBMS)
+	if err != nil {
+		return err
+	}
+	sourceMetadata = metadata
+	sourceConfig = config
+
 	// remove existing .py files on target directory
-	err := removeExistingPythonFiles(folder)
+	err = removeExistingPythonFiles(folder)
 	if err != nil {
 		return err
 	}
@@ -333,7 +688,13 @@ func WritePython(connInfo *config.ConnectionInfo, folder string, metadata *metad
 	}
 
 	// generate db connector
-	err = generatePythonDbConnector(connInfo, folder)
+	err = generatePythonDbConnector(&config.ConnInfo, folder)
+	if err != nil {
+		return err
+	}
+
+	// generate enum classes
+	err = generatePythonEnums(folder, metadata)
 	if err != nil {
 		return err
 	}
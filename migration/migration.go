@@ -0,0 +1,552 @@
+package migration
+
+import (
+	"dto-gen/metadata"
+	"dto-gen/metadiff"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// words that can't be used as bare identifiers in PostgreSQL
+var reservedWords = []string{
+	"all", "analyse", "analyze", "and", "any", "array", "as", "asc", "asymmetric", "both", "case", "cast",
+	"check", "collate", "column", "constraint", "create", "current_catalog", "current_date", "current_role",
+	"current_time", "current_timestamp", "current_user", "default", "deferrable", "desc", "distinct", "do",
+	"else", "end", "except", "false", "fetch", "for", "foreign", "from", "grant", "group", "having", "in",
+	"initially", "intersect", "into", "lateral", "leading", "limit", "localtime", "localtimestamp", "not",
+	"null", "offset", "on", "only", "or", "order", "placing", "primary", "references", "returning", "select",
+	"session_user", "some", "symmetric", "table", "then", "to", "trailing", "true", "union", "unique", "user",
+	"using", "variadic", "when", "where", "window", "with",
+}
+
+var simpleIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+func quoteIdent(name string) string {
+	if simpleIdentifier.MatchString(name) && !metadata.ContainsString(reservedWords, name) {
+		return name
+	}
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+func qualifiedName(schema string, name string) string {
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// types whose modifiers, such as the length of character varying or the
+// precision of numeric, belong to the column definition
+var modifiedTypes = []string{
+	"bit", "bit varying", "character", "character varying", "interval", "numeric", "time with time zone",
+	"time without time zone", "timestamp with time zone", "timestamp without time zone",
+}
+
+func columnType(col *metadata.Column) (string, error) {
+	if col.IsArray() && col.ElementType == "USER-DEFINED" && col.UdtName != "" {
+		return qualifiedName(col.UdtSchema, col.ElementUdtName()) + strings.Repeat("[]", max(col.ArrayDims, 1)), nil
+	}
+	if col.Datatype == "USER-DEFINED" && col.UdtName != "" {
+		return qualifiedName(col.UdtSchema, col.UdtName), nil
+	}
+	datatype := col.Datatype
+	if col.IsArray() {
+		datatype = col.ElementType
+	}
+	if datatype == "" || datatype == "ARRAY" || datatype == "USER-DEFINED" {
+		return "", fmt.Errorf("can't write the type of column %s: %s types are not known in detail", col.Name, col.Datatype)
+	}
+	if col.FormattedType != "" {
+		datatype = col.FormattedType
+	} else if metadata.ContainsString(modifiedTypes, datatype) {
+		// writing the bare type would drop a length or precision
+		return "", fmt.Errorf("can't write the type of column %s: the snapshot doesn't keep the modifiers of %s, take it again",
+			col.Name, datatype)
+	}
+	if col.IsArray() {
+		return datatype + strings.Repeat("[]", max(col.ArrayDims, 1)), nil
+	}
+	return datatype, nil
+}
+
+func columnDefinition(col *metadata.Column) (string, error) {
+	datatype, err := columnType(col)
+	if err != nil {
+		return "", err
+	}
+	def := quoteIdent(col.Name) + " " + datatype
+	if col.IsAutoIncrement {
+		// the sequence of serial columns is not part of the metadata, so they become identities
+		def += " GENERATED BY DEFAULT AS IDENTITY"
+	} else if col.DefaultValue != nil {
+		def += " DEFAULT " + *col.DefaultValue
+	}
+	if !col.Nullable {
+		def += " NOT NULL"
+	}
+	return def, nil
+}
+
+func primaryKeyColumns(table *metadata.Table) []string {
+	columns := make([]string, 0)
+	for i := range table.Columns {
+		if table.Columns[i].IsPrimaryKey {
+			columns = append(columns, table.Columns[i].Name)
+		}
+	}
+	return columns
+}
+
+// primaryKey returns the primary key constraint of a table, made up from the
+// key columns when the metadata has none, or nil when the table has no key
+func primaryKey(table *metadata.Table) *metadata.Constraint {
+	pks := table.SearchConstraints(metadata.PrimaryKeyConstraint)
+	if len(pks) > 0 {
+		return pks[0]
+	}
+	columns := primaryKeyColumns(table)
+	if len(columns) == 0 {
+		return nil
+	}
+	return &metadata.Constraint{
+		Name:    metadata.DefaultConstraintName(table.Name, metadata.PrimaryKeyConstraint, columns),
+		Type:    metadata.PrimaryKeyConstraint,
+		Columns: columns,
+	}
+}
+
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i := range names {
+		quoted[i] = quoteIdent(names[i])
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func findTable(meta *metadata.Metadata, schema string, name string) *metadata.Table {
+	for i := range meta.Tables {
+		if meta.Tables[i].Schema == schema && meta.Tables[i].Name == name {
+			return &meta.Tables[i]
+		}
+	}
+	return nil
+}
+
+func searchConstraint(table *metadata.Table, name string) *metadata.Constraint {
+	for i := range table.Constraints {
+		if table.Constraints[i].Name == name {
+			return &table.Constraints[i]
+		}
+	}
+	return nil
+}
+
+func searchIndex(table *metadata.Table, name string) *metadata.Index {
+	for i := range table.Indexes {
+		if table.Indexes[i].Name == name {
+			return &table.Indexes[i]
+		}
+	}
+	return nil
+}
+
+func constraintDefinition(constraint *metadata.Constraint) (string, error) {
+	def := "CONSTRAINT " + quoteIdent(constraint.Name) + " "
+	switch constraint.Type {
+	case metadata.PrimaryKeyConstraint, metadata.UniqueConstraint:
+		return def + constraint.Type + " (" + quoteList(constraint.Columns) + ")", nil
+	case metadata.ForeignKeyConstraint:
+		def += fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quoteList(constraint.Columns),
+			qualifiedName(constraint.RefSchema, constraint.RefTable), quoteList(constraint.RefColumns))
+		if constraint.OnDelete != "" {
+			def += " ON DELETE " + constraint.OnDelete
+		}
+		if constraint.OnUpdate != "" {
+			def += " ON UPDATE " + constraint.OnUpdate
+		}
+		return def, nil
+	}
+	return "", fmt.Errorf("can't write constraint %s: %s constraints are not supported", constraint.Name, constraint.Type)
+}
+
+func addConstraint(table *metadata.Table, constraint *metadata.Constraint) (string, error) {
+	def, err := constraintDefinition(constraint)
+	if err != nil {
+		return "", fmt.Errorf("table %s.%s: %w", table.Schema, table.Name, err)
+	}
+	return "ALTER TABLE " + qualifiedName(table.Schema, table.Name) + " ADD " + def + ";", nil
+}
+
+func dropConstraint(table *metadata.Table, constraint *metadata.Constraint) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;",
+		qualifiedName(table.Schema, table.Name), quoteIdent(constraint.Name))
+}
+
+func createIndex(table *metadata.Table, index *metadata.Index) string {
+	unique := ""
+	if index.IsUnique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, quoteIdent(index.Name),
+		qualifiedName(table.Schema, table.Name), quoteList(index.Columns))
+}
+
+func dropIndex(table *metadata.Table, index *metadata.Index) string {
+	return "DROP INDEX " + qualifiedName(table.Schema, index.Name) + ";"
+}
+
+func createEnum(enum *metadata.Enum) string {
+	labels := make([]string, len(enum.Labels))
+	for i := range enum.Labels {
+		labels[i] = quoteLiteral(enum.Labels[i])
+	}
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", qualifiedName(enum.Schema, enum.Name), strings.Join(labels, ", "))
+}
+
+// addEnumLabel keeps the label in place, before the first label following
+// it that the old enum already had
+func addEnumLabel(oldEnum *metadata.Enum, newEnum *metadata.Enum, label string) string {
+	statement := fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", qualifiedName(newEnum.Schema, newEnum.Name), quoteLiteral(label))
+	found := false
+	for i := range newEnum.Labels {
+		if newEnum.Labels[i] == label {
+			found = true
+		} else if found && metadata.ContainsString(oldEnum.Labels, newEnum.Labels[i]) {
+			return statement + " BEFORE " + quoteLiteral(newEnum.Labels[i]) + ";"
+		}
+	}
+	return statement + ";"
+}
+
+// createTable writes the table along with its primary key and unique
+// constraints, and returns apart the statements adding its foreign keys, which
+// have to wait for the tables they reference
+func createTable(table *metadata.Table) (string, []string, error) {
+	lines := make([]string, 0)
+	for i := range table.Columns {
+		def, err := columnDefinition(&table.Columns[i])
+		if err != nil {
+			return "", nil, fmt.Errorf("table %s.%s: %w", table.Schema, table.Name, err)
+		}
+		lines = append(lines, "    "+def)
+	}
+	foreignKeys := make([]string, 0)
+	if pk := primaryKey(table); pk != nil {
+		def, err := constraintDefinition(pk)
+		if err != nil {
+			return "", nil, fmt.Errorf("table %s.%s: %w", table.Schema, table.Name, err)
+		}
+		lines = append(lines, "    "+def)
+	}
+	for i := range table.Constraints {
+		constraint := &table.Constraints[i]
+		if constraint.Type == metadata.PrimaryKeyConstraint {
+			continue
+		}
+		if constraint.Type == metadata.ForeignKeyConstraint {
+			statement, err := addConstraint(table, constraint)
+			if err != nil {
+				return "", nil, err
+			}
+			foreignKeys = append(foreignKeys, statement)
+			continue
+		}
+		def, err := constraintDefinition(constraint)
+		if err != nil {
+			return "", nil, fmt.Errorf("table %s.%s: %w", table.Schema, table.Name, err)
+		}
+		lines = append(lines, "    "+def)
+	}
+	create := "CREATE TABLE " + qualifiedName(table.Schema, table.Name) + " (\n" + strings.Join(lines, ",\n") + "\n);"
+	return create, foreignKeys, nil
+}
+
+// script collects the statements of a migration in the order they have to run
+type script struct {
+	dropForeignKeys []string
+	dropConstraints []string
+	dropIndexes     []string
+	dropPrimaryKeys []string
+	createTypes     []string
+	createTables    []string
+	alterColumns    []string
+	dropColumns     []string
+	dropTables      []string
+	dropTypes       []string
+	addPrimaryKeys  []string
+	addConstraints  []string
+	createIndexes   []string
+	addForeignKeys  []string
+}
+
+func (s *script) text() string {
+	text := "BEGIN;\n\n"
+	sections := [][]string{
+		s.dropForeignKeys, s.dropConstraints, s.dropIndexes, s.dropPrimaryKeys, s.createTypes, s.createTables,
+		s.alterColumns, s.dropColumns, s.dropTables, s.dropTypes, s.addPrimaryKeys, s.addConstraints,
+		s.createIndexes, s.addForeignKeys,
+	}
+	for i := range sections {
+		if len(sections[i]) == 0 {
+			continue
+		}
+		text += strings.Join(sections[i], "\n") + "\n\n"
+	}
+	text += "COMMIT;\n"
+	return text
+}
+
+// Generate writes the PostgreSQL statements that migrate a database from the
+// old schema to the new one
+func Generate(oldSnapshot *metadata.Snapshot, newSnapshot *metadata.Snapshot) (string, error) {
+	if newSnapshot.DBMS != "PostgreSQL" {
+		return "", fmt.Errorf("migrations can only be generated for PostgreSQL, not %s", newSnapshot.DBMS)
+	}
+	// overrides only tell whether changes break the generated code
+	changes, err := metadiff.Diff(oldSnapshot, newSnapshot, nil)
+	if err != nil {
+		return "", err
+	}
+	oldMeta := &oldSnapshot.Metadata
+	newMeta := &newSnapshot.Metadata
+
+	s := script{}
+	pkChanged := make(map[string]bool)
+	for i := range changes {
+		change := changes[i]
+		oldTable := findTable(oldMeta, change.Schema, change.Table)
+		newTable := findTable(newMeta, change.Schema, change.Table)
+		tableName := qualifiedName(change.Schema, change.Table)
+
+		var oldCol *metadata.Column
+		var newCol *metadata.Column
+		if oldTable != nil {
+			oldCol = oldTable.SearchColumnByName(change.Column)
+		}
+		if newTable != nil {
+			newCol = newTable.SearchColumnByName(change.Column)
+		}
+
+		switch change.Kind {
+		case metadiff.TableAdded:
+			statement, foreignKeys, err := createTable(newTable)
+			if err != nil {
+				return "", err
+			}
+			s.createTables = append(s.createTables, statement)
+			s.addForeignKeys = append(s.addForeignKeys, foreignKeys...)
+			for j := range newTable.Indexes {
+				index := &newTable.Indexes[j]
+				if !index.IsPrimary && searchConstraint(newTable, index.Name) == nil {
+					s.createIndexes = append(s.createIndexes, createIndex(newTable, index))
+				}
+			}
+
+		case metadiff.TableRemoved:
+			for _, fk := range oldTable.SearchConstraints(metadata.ForeignKeyConstraint) {
+				s.dropForeignKeys = append(s.dropForeignKeys, dropConstraint(oldTable, fk))
+			}
+			s.dropTables = append(s.dropTables, "DROP TABLE "+tableName+";")
+
+		case metadiff.ColumnAdded:
+			def, err := columnDefinition(newCol)
+			if err != nil {
+				return "", fmt.Errorf("table %s.%s: %w", change.Schema, change.Table, err)
+			}
+			s.alterColumns = append(s.alterColumns, "ALTER TABLE "+tableName+" ADD COLUMN "+def+";")
+			if newCol.IsPrimaryKey {
+				pkChanged[tableName] = true
+			}
+
+		case metadiff.ColumnRemoved:
+			if oldCol.IsPrimaryKey {
+				pkChanged[tableName] = true
+			}
+			s.dropColumns = append(s.dropColumns, "ALTER TABLE "+tableName+" DROP COLUMN "+quoteIdent(oldCol.Name)+";")
+
+		case metadiff.TypeChanged:
+			datatype, err := columnType(newCol)
+			if err != nil {
+				return "", fmt.Errorf("table %s.%s: %w", change.Schema, change.Table, err)
+			}
+			s.alterColumns = append(s.alterColumns, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+				tableName, quoteIdent(newCol.Name), datatype, quoteIdent(newCol.Name), datatype))
+
+		case metadiff.NullabilityChanged:
+			action := "SET NOT NULL"
+			if newCol.Nullable {
+				action = "DROP NOT NULL"
+			}
+			s.alterColumns = append(s.alterColumns, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;",
+				tableName, quoteIdent(newCol.Name), action))
+
+		case metadiff.PrimaryKeyChanged:
+			pkChanged[tableName] = true
+
+		case metadiff.ForeignKeyChanged:
+			// the constraint changes of the foreign key carry its statements
+
+		case metadiff.ConstraintAdded, metadiff.ConstraintRemoved, metadiff.ConstraintChanged:
+			if change.Kind != metadiff.ConstraintAdded {
+				constraint := searchConstraint(oldTable, change.Column)
+				if constraint == nil {
+					return "", fmt.Errorf("constraint %s of table %s.%s not found", change.Column, change.Schema, change.Table)
+				}
+				if constraint.Type == metadata.ForeignKeyConstraint {
+					s.dropForeignKeys = append(s.dropForeignKeys, dropConstraint(oldTable, constraint))
+				} else {
+					s.dropConstraints = append(s.dropConstraints, dropConstraint(oldTable, constraint))
+				}
+			}
+			if change.Kind != metadiff.ConstraintRemoved {
+				constraint := searchConstraint(newTable, change.Column)
+				if constraint == nil {
+					return "", fmt.Errorf("constraint %s of table %s.%s not found", change.Column, change.Schema, change.Table)
+				}
+				statement, err := addConstraint(newTable, constraint)
+				if err != nil {
+					return "", err
+				}
+				if constraint.Type == metadata.ForeignKeyConstraint {
+					s.addForeignKeys = append(s.addForeignKeys, statement)
+				} else {
+					s.addConstraints = append(s.addConstraints, statement)
+				}
+			}
+
+		case metadiff.IndexAdded, metadiff.IndexRemoved, metadiff.IndexChanged:
+			// the indexes backing unique constraints come and go with them
+			if change.Kind != metadiff.IndexAdded && searchConstraint(oldTable, change.Column) == nil {
+				index := searchIndex(oldTable, change.Column)
+				if index == nil {
+					return "", fmt.Errorf("index %s of table %s.%s not found", change.Column, change.Schema, change.Table)
+				}
+				s.dropIndexes = append(s.dropIndexes, dropIndex(oldTable, index))
+			}
+			if change.Kind != metadiff.IndexRemoved && searchConstraint(newTable, change.Column) == nil {
+				index := searchIndex(newTable, change.Column)
+				if index == nil {
+					return "", fmt.Errorf("index %s of table %s.%s not found", change.Column, change.Schema, change.Table)
+				}
+				s.createIndexes = append(s.createIndexes, createIndex(newTable, index))
+			}
+
+		case metadiff.EnumAdded:
+			s.createTypes = append(s.createTypes, createEnum(newMeta.SearchEnum(change.Schema, change.Table)))
+
+		case metadiff.EnumRemoved:
+			s.dropTypes = append(s.dropTypes, "DROP TYPE "+tableName+";")
+
+		case metadiff.EnumLabelAdded:
+			s.createTypes = append(s.createTypes, addEnumLabel(oldMeta.SearchEnum(change.Schema, change.Table),
+				newMeta.SearchEnum(change.Schema, change.Table), change.Column))
+
+		case metadiff.EnumLabelRemoved:
+			// there is no ALTER TYPE ... DROP VALUE, the type has to be rebuilt by hand
+			s.createTypes = append(s.createTypes, fmt.Sprintf("-- TODO: label %s can't be dropped from %s, recreate the type",
+				quoteLiteral(change.Column), tableName))
+
+		case metadiff.AutoIncrementChanged:
+			action := "DROP IDENTITY IF EXISTS"
+			if newCol.IsAutoIncrement {
+				action = "ADD GENERATED BY DEFAULT AS IDENTITY"
+			}
+			s.alterColumns = append(s.alterColumns, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;",
+				tableName, quoteIdent(newCol.Name), action))
+
+		case metadiff.TypeRenamed:
+			// only the generated code is renamed
+
+		default:
+			return "", fmt.Errorf("can't migrate %s of %s", change.Kind, tableName)
+		}
+	}
+
+	// primary keys are rebuilt as a whole, whatever column changed
+	for i := range changes {
+		tableName := qualifiedName(changes[i].Schema, changes[i].Table)
+		if !pkChanged[tableName] {
+			continue
+		}
+		delete(pkChanged, tableName)
+
+		oldTable := findTable(oldMeta, changes[i].Schema, changes[i].Table)
+		newTable := findTable(newMeta, changes[i].Schema, changes[i].Table)
+		if pk := primaryKey(oldTable); pk != nil {
+			s.dropPrimaryKeys = append(s.dropPrimaryKeys, dropConstraint(oldTable, pk))
+		}
+		if pk := primaryKey(newTable); pk != nil {
+			statement, err := addConstraint(newTable, pk)
+			if err != nil {
+				return "", err
+			}
+			s.addPrimaryKeys = append(s.addPrimaryKeys, statement)
+		}
+	}
+
+	return s.text(), nil
+}
+
+var migrationNumber = regexp.MustCompile(`^(\d+)_`)
+
+// nextMigrationNumber returns the number following the highest numbered file in folder
+func nextMigrationNumber(folder string) (int, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return 0, err
+	}
+	next := 1
+	for i := range entries {
+		match := migrationNumber.FindStringSubmatch(entries[i].Name())
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err == nil && number >= next {
+			next = number + 1
+		}
+	}
+	return next, nil
+}
+
+// WriteMigration writes the forward (up) and backward (down) scripts between
+// two snapshots as the next numbered migration in folder, and returns the
+// paths of both files
+func WriteMigration(oldSnapshot *metadata.Snapshot, newSnapshot *metadata.Snapshot, folder string, name string) (string, string, error) {
+	up, err := Generate(oldSnapshot, newSnapshot)
+	if err != nil {
+		return "", "", err
+	}
+	down, err := Generate(newSnapshot, oldSnapshot)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = os.MkdirAll(folder, 0755)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating migrations folder: %w", err)
+	}
+	number, err := nextMigrationNumber(folder)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading migrations folder: %w", err)
+	}
+
+	baseName := fmt.Sprintf("%04d_%s", number, name)
+	upFile := filepath.Join(folder, baseName+".up.sql")
+	downFile := filepath.Join(folder, baseName+".down.sql")
+	err = os.WriteFile(upFile, []byte(up), 0644)
+	if err != nil {
+		return "", "", fmt.Errorf("error writing migration: %w", err)
+	}
+	err = os.WriteFile(downFile, []byte(down), 0644)
+	if err != nil {
+		return "", "", fmt.Errorf("error writing migration: %w", err)
+	}
+
+	return upFile, downFile, nil
+}
@@ -0,0 +1,229 @@
+package migration
+
+import (
+	"dto-gen/metadata"
+	"strings"
+	"testing"
+)
+
+func snapshot(tables ...metadata.Table) *metadata.Snapshot {
+	return &metadata.Snapshot{
+		Version:  metadata.SnapshotVersion,
+		DBMS:     "PostgreSQL",
+		Metadata: metadata.Metadata{Database: "shop", Tables: tables},
+	}
+}
+
+func customer() metadata.Table {
+	return metadata.Table{
+		Schema: "public",
+		Name:   "customer",
+		Columns: []metadata.Column{
+			{Ordinal: 1, Name: "id", Datatype: "integer", FormattedType: "integer", IsPrimaryKey: true, IsAutoIncrement: true},
+			{Ordinal: 2, Name: "email", Datatype: "character varying", FormattedType: "character varying(40)", Nullable: true},
+		},
+		Constraints: []metadata.Constraint{
+			{Name: "customer_pk", Type: metadata.PrimaryKeyConstraint, Columns: []string{"id"}},
+		},
+		Indexes: []metadata.Index{
+			{Name: "customer_pk", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+		},
+	}
+}
+
+func invoice() metadata.Table {
+	return metadata.Table{
+		Schema: "public",
+		Name:   "invoice",
+		Columns: []metadata.Column{
+			{Ordinal: 1, Name: "region", Datatype: "character", FormattedType: "character(2)", IsPrimaryKey: true},
+			{Ordinal: 2, Name: "num", Datatype: "integer", FormattedType: "integer", IsPrimaryKey: true},
+			{Ordinal: 3, Name: "total", Datatype: "numeric", FormattedType: "numeric(10,2)"},
+		},
+		Constraints: []metadata.Constraint{
+			{Name: "invoice_pkey", Type: metadata.PrimaryKeyConstraint, Columns: []string{"region", "num"}},
+		},
+	}
+}
+
+func line() metadata.Table {
+	return metadata.Table{
+		Schema: "public",
+		Name:   "line",
+		Columns: []metadata.Column{
+			{Ordinal: 1, Name: "region", Datatype: "character", FormattedType: "character(2)"},
+			{Ordinal: 2, Name: "num", Datatype: "integer", FormattedType: "integer"},
+			{Ordinal: 3, Name: "pos", Datatype: "integer", FormattedType: "integer"},
+		},
+		Constraints: []metadata.Constraint{
+			{Name: "line_invoice_fk", Type: metadata.ForeignKeyConstraint, Columns: []string{"region", "num"},
+				RefSchema: "public", RefTable: "invoice", RefColumns: []string{"region", "num"}, OnDelete: "CASCADE"},
+			{Name: "line_pos_key", Type: metadata.UniqueConstraint, Columns: []string{"region", "num", "pos"}},
+		},
+		Indexes: []metadata.Index{
+			{Name: "line_pos_key", Columns: []string{"region", "num", "pos"}, IsUnique: true},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	emailKey := customer()
+	emailKey.Constraints = append(emailKey.Constraints,
+		metadata.Constraint{Name: "customer_email_key", Type: metadata.UniqueConstraint, Columns: []string{"email"}})
+	emailKey.Indexes = append(emailKey.Indexes,
+		metadata.Index{Name: "customer_email_key", Columns: []string{"email"}, IsUnique: true})
+
+	emailIndex := customer()
+	emailIndex.Indexes = append(emailIndex.Indexes, metadata.Index{Name: "customer_email", Columns: []string{"email"}})
+
+	longerEmail := customer()
+	longerEmail.Columns[1].FormattedType = "character varying(80)"
+
+	withCustomer := line()
+	withCustomer.Columns = append(withCustomer.Columns,
+		metadata.Column{Ordinal: 4, Name: "customer_id", Datatype: "integer", FormattedType: "integer", Nullable: true,
+			FkTarget: &metadata.ForeignKeyTarget{Schema: "public", Table: "customer", Column: "id"}})
+	withCustomer.Constraints = append(withCustomer.Constraints,
+		metadata.Constraint{Name: "line_customer_id_fkey", Type: metadata.ForeignKeyConstraint, Columns: []string{"customer_id"},
+			RefSchema: "public", RefTable: "customer", RefColumns: []string{"id"}, OnUpdate: "SET NULL"})
+
+	widerKey := invoice()
+	widerKey.Columns[2].IsPrimaryKey = true
+	widerKey.Constraints[0].Columns = []string{"region", "num", "total"}
+
+	tests := []struct {
+		name string
+		old  *metadata.Snapshot
+		new  *metadata.Snapshot
+		up   []string
+		down []string
+	}{
+		{
+			name: "tables with composite keys",
+			old:  snapshot(),
+			new:  snapshot(invoice(), line()),
+			up: []string{
+				"CREATE TABLE public.invoice (\n" +
+					"    region character(2) NOT NULL,\n" +
+					"    num integer NOT NULL,\n" +
+					"    total numeric(10,2) NOT NULL,\n" +
+					"    CONSTRAINT invoice_pkey PRIMARY KEY (region, num)\n" +
+					");\n" +
+					"CREATE TABLE public.line (\n" +
+					"    region character(2) NOT NULL,\n" +
+					"    num integer NOT NULL,\n" +
+					"    pos integer NOT NULL,\n" +
+					"    CONSTRAINT line_pos_key UNIQUE (region, num, pos)\n" +
+					");",
+				"ALTER TABLE public.line ADD CONSTRAINT line_invoice_fk FOREIGN KEY (region, num) " +
+					"REFERENCES public.invoice (region, num) ON DELETE CASCADE;",
+			},
+			down: []string{
+				"ALTER TABLE public.line DROP CONSTRAINT line_invoice_fk;",
+				"DROP TABLE public.invoice;\nDROP TABLE public.line;",
+			},
+		},
+		{
+			name: "unique constraint",
+			old:  snapshot(customer()),
+			new:  snapshot(emailKey),
+			up:   []string{"ALTER TABLE public.customer ADD CONSTRAINT customer_email_key UNIQUE (email);"},
+			down: []string{"ALTER TABLE public.customer DROP CONSTRAINT customer_email_key;"},
+		},
+		{
+			name: "index",
+			old:  snapshot(customer()),
+			new:  snapshot(emailIndex),
+			up:   []string{"CREATE INDEX customer_email ON public.customer (email);"},
+			down: []string{"DROP INDEX public.customer_email;"},
+		},
+		{
+			name: "length change",
+			old:  snapshot(customer()),
+			new:  snapshot(longerEmail),
+			up: []string{"ALTER TABLE public.customer ALTER COLUMN email TYPE character varying(80) " +
+				"USING email::character varying(80);"},
+			down: []string{"ALTER TABLE public.customer ALTER COLUMN email TYPE character varying(40) " +
+				"USING email::character varying(40);"},
+		},
+		{
+			name: "primary key",
+			old:  snapshot(invoice()),
+			new:  snapshot(widerKey),
+			up: []string{
+				"ALTER TABLE public.invoice DROP CONSTRAINT invoice_pkey;",
+				"ALTER TABLE public.invoice ADD CONSTRAINT invoice_pkey PRIMARY KEY (region, num, total);",
+			},
+			down: []string{
+				"ALTER TABLE public.invoice DROP CONSTRAINT invoice_pkey;",
+				"ALTER TABLE public.invoice ADD CONSTRAINT invoice_pkey PRIMARY KEY (region, num);",
+			},
+		},
+		{
+			name: "foreign key column",
+			old:  snapshot(customer(), invoice(), line()),
+			new:  snapshot(customer(), invoice(), withCustomer),
+			up: []string{
+				"ALTER TABLE public.line ADD COLUMN customer_id integer;",
+				"ALTER TABLE public.line ADD CONSTRAINT line_customer_id_fkey FOREIGN KEY (customer_id) " +
+					"REFERENCES public.customer (id) ON UPDATE SET NULL;",
+			},
+			down: []string{
+				"ALTER TABLE public.line DROP CONSTRAINT line_customer_id_fkey;",
+				"ALTER TABLE public.line DROP COLUMN customer_id;",
+			},
+		},
+	}
+	for _, tt := range tests {
+		up, err := Generate(tt.old, tt.new)
+		if err != nil {
+			t.Fatalf("%s: Generate() error: %v", tt.name, err)
+		}
+		down, err := Generate(tt.new, tt.old)
+		if err != nil {
+			t.Fatalf("%s: Generate() of the down migration error: %v", tt.name, err)
+		}
+		for _, script := range []struct {
+			direction string
+			got       string
+			want      []string
+		}{{"up", up, tt.up}, {"down", down, tt.down}} {
+			want := "BEGIN;\n\n" + strings.Join(script.want, "\n\n") + "\n\nCOMMIT;\n"
+			if script.got != want {
+				t.Errorf("%s: %s migration\n%s\nwant\n%s", tt.name, script.direction, script.got, want)
+			}
+		}
+	}
+}
+
+func TestColumnType(t *testing.T) {
+	tests := []struct {
+		name     string
+		col      metadata.Column
+		datatype string
+	}{
+		{"formatted", metadata.Column{Datatype: "numeric", FormattedType: "numeric(10,2)"}, "numeric(10,2)"},
+		{"no modifiers", metadata.Column{Datatype: "text"}, "text"},
+		{"array", metadata.Column{Datatype: "ARRAY", ElementType: "character varying", FormattedType: "character varying(5)", ArrayDims: 2}, "character varying(5)[][]"},
+		{"enum", metadata.Column{Datatype: "USER-DEFINED", UdtSchema: "public", UdtName: "mood"}, "public.mood"},
+		{"enum array", metadata.Column{Datatype: "ARRAY", ElementType: "USER-DEFINED", UdtSchema: "public", UdtName: "_mood", ArrayDims: 1}, "public.mood[]"},
+		{"modifiers not recorded", metadata.Column{Datatype: "character varying"}, ""},
+		{"array modifiers not recorded", metadata.Column{Datatype: "ARRAY", ElementType: "numeric"}, ""},
+		{"unknown user defined", metadata.Column{Datatype: "USER-DEFINED"}, ""},
+	}
+	for _, tt := range tests {
+		tt.col.Name = "c"
+		datatype, err := columnType(&tt.col)
+		if tt.datatype == "" {
+			if err == nil {
+				t.Errorf("%s: columnType() = %q, want an error", tt.name, datatype)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: columnType() error: %v", tt.name, err)
+		} else if datatype != tt.datatype {
+			t.Errorf("%s: columnType() = %q, want %q", tt.name, datatype, tt.datatype)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package mysql
+
+import (
+	"database/sql"
+	"dto-gen/config"
+	"dto-gen/metadata"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// MySQLToGolangTypes maps data types to go types. Decimals are scanned as
+// text, so no precision is lost.
+var MySQLToGolangTypes = map[string]string{
+	"bigint":             "int64",
+	"bigint unsigned":    "uint64",
+	"binary":             "[]byte",
+	"bit":                "[]byte",
+	"blob":               "[]byte",
+	"bool":               "bool",
+	"boolean":            "bool",
+	"char":               "string",
+	"date":               "time.Time",
+	"datetime":           "time.Time",
+	"decimal":            "string",
+	"double":             "float64",
+	"enum":               "string",
+	"float":              "float32",
+	"int":                "int32",
+	"int unsigned":       "uint32",
+	"json":               "json.RawMessage",
+	"longblob":           "[]byte",
+	"longtext":           "string",
+	"mediumblob":         "[]byte",
+	"mediumint":          "int32",
+	"mediumint unsigned": "uint32",
+	"mediumtext":         "string",
+	"set":                "string",
+	"smallint":           "int16",
+	"smallint unsigned":  "uint16",
+	"text":               "string",
+	"time":               "string",
+	"timestamp":          "time.Time",
+	"tinyblob":           "[]byte",
+	"tinyint":            "int8",
+	"tinyint unsigned":   "uint8",
+	"tinytext":           "string",
+	"varbinary":          "[]byte",
+	"varchar":            "string",
+	"year":               "int16",
+}
+
+var MySQLToPythonTypes = map[string]string{
+	"bigint":             "int",
+	"bigint unsigned":    "int",
+	"binary":             "bytes",
+	"bit":                "bytes",
+	"blob":               "bytes",
+	"bool":               "bool",
+	"boolean":            "bool",
+	"char":               "str",
+	"date":               "datetime.date",
+	"datetime":           "datetime.datetime",
+	"decimal":            "decimal.Decimal",
+	"double":             "float",
+	"enum":               "str",
+	"float":              "float",
+	"int":                "int",
+	"int unsigned":       "int",
+	"json":               "dict | list",
+	"longblob":           "bytes",
+	"longtext":           "str",
+	"mediumblob":         "bytes",
+	"mediumint":          "int",
+	"mediumint unsigned": "int",
+	"mediumtext":         "str",
+	"set":                "str",
+	"smallint":           "int",
+	"smallint unsigned":  "int",
+	"text":               "str",
+	"time":               "datetime.timedelta",
+	"timestamp":          "datetime.datetime",
+	"tinyblob":           "bytes",
+	"tinyint":            "int",
+	"tinyint unsigned":   "int",
+	"tinytext":           "str",
+	"varbinary":          "bytes",
+	"varchar":            "str",
+	"year":               "int",
+}
+
+type MyIndex struct {
+	Schema string
+	Table  string
+	Index  metadata.Index
+}
+
+type MyConstraint struct {
+	Schema     string
+	Table      string
+	Constraint metadata.Constraint
+}
+
+func connectToMySQL(connInfo config.ConnectionInfo) (*sql.DB, error) {
+	var dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+		connInfo.Username, connInfo.Password, connInfo.Host, connInfo.Port, connInfo.Database)
+	fmt.Printf("Connection String: %s\n", dsn)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to mysql: %w", err)
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to mysql: %w", err)
+	}
+	return db, nil
+}
+
+// schemaFilter returns the "?, ?, ..." placeholder list and its arguments for
+// an IN clause over the given schemas.
+func schemaFilter(schemas []string) (string, []any) {
+	placeholders := make([]string, len(schemas))
+	args := make([]any, len(schemas))
+	for i := range schemas {
+		placeholders[i] = "?"
+		args[i] = schemas[i]
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+func readMySQLTables(db *sql.DB, schemas []string) ([]metadata.Table, error) {
+	in, args := schemaFilter(schemas)
+	var query = `
+		SELECT table_schema, table_name,
+			CASE WHEN table_type = 'VIEW' THEN '' ELSE table_comment END
+		FROM information_schema.tables
+		WHERE table_schema IN (` + in + `) AND table_type IN ('BASE TABLE', 'VIEW')
+		ORDER BY table_schema, table_name
+	`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table list: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []metadata.Table
+	for rows.Next() {
+		var table metadata.Table
+		err := rows.Scan(&table.Schema, &table.Name, &table.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table list row: %w", err)
+		}
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over table list rows: %w", err)
+	}
+
+	return tables, nil
+}
+
+func readMySQLColumns(db *sql.DB, schemas []string) (map[string][]metadata.Column, error) {
+	in, args := schemaFilter(schemas)
+	var query = `
+		SELECT ordinal_position, table_schema, table_name, column_name, data_type, column_type,
+			is_nullable, column_default, column_key, extra, column_comment
+		FROM information_schema.columns
+		WHERE table_schema IN (` + in + `)
+		ORDER BY ordinal_position
+	`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns list: %w", err)
+	}
+	defer rows.Close()
+
+	var columnMap = make(map[string][]metadata.Column)
+	for rows.Next() {
+		var column metadata.Column
+		var tableName string
+		var tableSchema string
+		var columnType string
+		var nullable string
+		var columnKey string
+		var extra string
+		err := rows.Scan(
+			&column.Ordinal,
+			&tableSchema,
+			&tableName,
+			&column.Name,
+			&column.Datatype,
+			&columnType,
+			&nullable,
+			&column.DefaultValue,
+			&columnKey,
+			&extra,
+			&column.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan columns list row: %w", err)
+		}
+		var key = fmt.Sprintf("%s.%s", tableSchema, tableName)
+		column.Datatype = strings.ToLower(column.Datatype)
+		column.FormattedType = strings.ToLower(columnType)
+		if strings.Contains(strings.ToLower(columnType), "unsigned") {
+			column.Datatype += " unsigned"
+		}
+		if strings.ToLower(columnType) == "tinyint(1)" {
+			column.Datatype = "boolean"
+		}
+		column.Nullable = nullable == "YES"
+		column.IsPrimaryKey = columnKey == "PRI"
+		column.IsAutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		columnMap[key] = append(columnMap[key], column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over columns list rows: %w", err)
+	}
+
+	return columnMap, nil
+}
+
+// readMySQLConstraints reads primary key, unique and foreign key
+// constraints, a row per column in key order. Constraint names are only
+// unique within a table, every primary key being named PRIMARY.
+func readMySQLConstraints(db *sql.DB, schemas []string) ([]MyConstraint, error) {
+	in, args := schemaFilter(schemas)
+	var query = `
+		SELECT
+			tc.table_schema,
+			tc.table_name,
+			tc.constraint_name,
+			tc.constraint_type,
+			kcu.column_name,
+			COALESCE(kcu.referenced_table_schema, '') AS referenced_schema,
+			COALESCE(kcu.referenced_table_name, '') AS referenced_table,
+			COALESCE(kcu.referenced_column_name, '') AS referenced_column,
+			COALESCE(rc.delete_rule, '') AS delete_rule,
+			COALESCE(rc.update_rule, '') AS update_rule
+		FROM information_schema.table_constraints tc
+			INNER JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_schema = tc.constraint_schema
+				AND kcu.table_name = tc.table_name
+				AND kcu.constraint_name = tc.constraint_name
+			LEFT JOIN information_schema.referential_constraints rc
+				ON rc.constraint_schema = tc.constraint_schema
+				AND rc.table_name = tc.table_name
+				AND rc.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+		  AND tc.table_schema IN (` + in + `)
+		ORDER BY tc.table_schema, tc.table_name,
+			FIELD(tc.constraint_type, 'PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY'),
+			tc.constraint_name, kcu.ordinal_position
+	`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query constraint list: %w", err)
+	}
+	defer rows.Close()
+
+	var constraints = make([]MyConstraint, 0)
+	for rows.Next() {
+		var schema, table, name, constraintType, column string
+		var refSchema, refTable, refColumn, deleteRule, updateRule string
+		err := rows.Scan(
+			&schema,
+			&table,
+			&name,
+			&constraintType,
+			&column,
+			&refSchema,
+			&refTable,
+			&refColumn,
+			&deleteRule,
+			&updateRule)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan constraint list row: %w", err)
+		}
+
+		// rows of the same constraint follow each other
+		last := len(constraints) - 1
+		if last < 0 || constraints[last].Schema != schema || constraints[last].Table != table ||
+			constraints[last].Constraint.Name != name {
+			constraint := MyConstraint{
+				Schema: schema,
+				Table:  table,
+				Constraint: metadata.Constraint{
+					Name: name,
+					Type: constraintType,
+				},
+			}
+			if constraintType == metadata.ForeignKeyConstraint {
+				constraint.Constraint.RefSchema = refSchema
+				constraint.Constraint.RefTable = refTable
+				constraint.Constraint.OnDelete = deleteRule
+				constraint.Constraint.OnUpdate = updateRule
+			}
+			constraints = append(constraints, constraint)
+			last++
+		}
+		constraints[last].Constraint.Columns = append(constraints[last].Constraint.Columns, column)
+		if constraintType == metadata.ForeignKeyConstraint {
+			constraints[last].Constraint.RefColumns = append(constraints[last].Constraint.RefColumns, refColumn)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over constraint list rows: %w", err)
+	}
+
+	return constraints, nil
+}
+
+// readMySQLIndexes reads the btree and hash indexes of the tables, a row per
+// column in key order. Functional indexes, having key parts without a
+// column, are skipped.
+func readMySQLIndexes(db *sql.DB, schemas []string) ([]MyIndex, error) {
+	in, args := schemaFilter(schemas)
+	var query = `
+		SELECT table_schema, table_name, index_name, non_unique, column_name
+		FROM information_schema.statistics
+		WHERE index_type IN ('BTREE', 'HASH')
+		  AND table_schema IN (` + in + `)
+		ORDER BY table_schema, table_name, index_name = 'PRIMARY' DESC, index_name, seq_in_index
+	`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index list: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes = make([]MyIndex, 0)
+	var functional []string
+	for rows.Next() {
+		var schema, table, name string
+		var nonUnique bool
+		var column *string
+		err := rows.Scan(&schema, &table, &name, &nonUnique, &column)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan index list row: %w", err)
+		}
+
+		// rows of the same index follow each other
+		last := len(indexes) - 1
+		if last < 0 || indexes[last].Schema != schema || indexes[last].Table != table || indexes[last].Index.Name != name {
+			indexes = append(indexes, MyIndex{
+				Schema: schema,
+				Table:  table,
+				Index: metadata.Index{
+					Name:      name,
+					IsUnique:  !nonUnique,
+					IsPrimary: name == "PRIMARY",
+				},
+			})
+			last++
+		}
+		if column == nil {
+			functional = append(functional, schema+"."+table+"."+name)
+			continue
+		}
+		indexes[last].Index.Columns = append(indexes[last].Index.Columns, *column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over index list rows: %w", err)
+	}
+
+	var plainIndexes = make([]MyIndex, 0)
+	for i := range indexes {
+		if !metadata.ContainsString(functional, indexes[i].Schema+"."+indexes[i].Table+"."+indexes[i].Index.Name) {
+			plainIndexes = append(plainIndexes, indexes[i])
+		}
+	}
+	return plainIndexes, nil
+}
+
+func ReadMySQLMetadata(config config.Config) (*metadata.Metadata, error) {
+	db, err := connectToMySQL(config.ConnInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// in MySQL a schema is a database, so default to the one we connected to
+	schemas := config.ConnInfo.Schemas
+	if len(schemas) == 0 {
+		schemas = []string{config.ConnInfo.Database}
+	}
+
+	// read tables
+	tables, err := readMySQLTables(db, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table list: %w", err)
+	}
+
+	// read columns, including primary key and auto increment flags
+	columnsMap, err := readMySQLColumns(db, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns list: %w", err)
+	}
+
+	// associate tables with columns
+	for i := 0; i < len(tables); i++ {
+		var key = fmt.Sprintf("%s.%s", tables[i].Schema, tables[i].Name)
+		columns, exists := columnsMap[key]
+		if exists {
+			tables[i].Columns = columns
+		}
+	}
+
+	// read constraints
+	constraints, err := readMySQLConstraints(db, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraint list: %w", err)
+	}
+
+	// read indexes
+	indexes, err := readMySQLIndexes(db, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index list: %w", err)
+	}
+
+	// attach indexes and constraints, marking single column foreign keys
+	for i := range tables {
+		for k := range indexes {
+			if indexes[k].Schema == tables[i].Schema && indexes[k].Table == tables[i].Name {
+				tables[i].Indexes = append(tables[i].Indexes, indexes[k].Index)
+			}
+		}
+		for k := range constraints {
+			if constraints[k].Schema != tables[i].Schema || constraints[k].Table != tables[i].Name {
+				continue
+			}
+			constraint := constraints[k].Constraint
+			tables[i].Constraints = append(tables[i].Constraints, constraint)
+			if constraint.Type != metadata.ForeignKeyConstraint || len(constraint.Columns) != 1 {
+				continue
+			}
+			col := tables[i].SearchColumnByName(constraint.Columns[0])
+			if col != nil {
+				col.FkTarget = &metadata.ForeignKeyTarget{
+					Schema: constraint.RefSchema,
+					Table:  constraint.RefTable,
+					Column: constraint.RefColumns[0],
+				}
+			}
+		}
+	}
+
+	return &metadata.Metadata{
+		Database: config.ConnInfo.Database,
+		Tables:   tables,
+	}, nil
+}
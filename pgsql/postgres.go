@@ -8,64 +8,33 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-var PostgreSQLToGolangTypes = map[string]string{
-	"bigint":                      "int64",
-	"bigserial":                   "int64",
-	"bit":                         "bool",
-	"boolean":                     "bool",
-	"bytea":                       "[]byte",
-	"character":                   "rune",
-	"character varying":           "string",
-	"date":                        "time.Time",
-	"double precision":            "float32",
-	"integer":                     "int",
-	"money":                       "float64",
-	"numeric":                     "float64",
-	"real":                        "float32",
-	"serial":                      "int",
-	"smallint":                    "int16",
-	"smallserial":                 "int16",
-	"text":                        "string",
-	"timestamp without time zone": "time.Time",
+type PgConstraint struct {
+	Schema     string
+	Table      string
+	Constraint metadata.Constraint
 }
 
-var PostgreSQLToPythonTypes = map[string]string{
-	"bigint":                      "int",
-	"bigserial":                   "int",
-	"bit":                         "bool",
-	"boolean":                     "bool",
-	"bytea":                       "[]byte",
-	"character":                   "str",
-	"character varying":           "str",
-	"date":                        "datetime.date",
-	"double precision":            "float",
-	"integer":                     "int",
-	"money":                       "float",
-	"numeric":                     "float",
-	"real":                        "float",
-	"serial":                      "int",
-	"smallint":                    "int",
-	"smallserial":                 "int",
-	"text":                        "str",
-	"timestamp without time zone": "datetime.datetime",
+// pgConstraintTypes maps pg_constraint.contype to constraint types
+var pgConstraintTypes = map[string]string{
+	"p": metadata.PrimaryKeyConstraint,
+	"u": metadata.UniqueConstraint,
+	"f": metadata.ForeignKeyConstraint,
 }
 
-type PgConstraints struct {
-	Schema string
-	Table  string
-	Column string
-	Name   string
-	Type   string
+// pgReferentialActions maps pg_constraint.confdeltype and confupdtype to
+// referential actions
+var pgReferentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
 }
 
-type PgFkInfo struct {
-	ConstraintName   string
-	FkSchema         string
-	FkTable          string
-	FkColumn         string
-	ReferencedSchema string
-	ReferencedTable  string
-	ReferencedColumn string
+type PgIndex struct {
+	Schema string
+	Table  string
+	Index  metadata.Index
 }
 
 type PgAutoIncrementInfo struct {
@@ -89,7 +58,8 @@ func connectToPostgres(connInfo config.ConnectionInfo) (*pgx.Conn, error) {
 
 func readPgTables(conn *pgx.Conn, schemas []string) ([]metadata.Table, error) {
 	var query = `
-		SELECT table_schema, table_name
+		SELECT table_schema, table_name,
+		       COALESCE(obj_description(format('%I.%I', table_schema, table_name)::regclass, 'pg_class'), '')
 		FROM information_schema.tables
 		WHERE table_schema IN (
 	`
@@ -100,6 +70,8 @@ func readPgTables(conn *pgx.Conn, schemas []string) ([]metadata.Table, error) {
 		query += "'" + schemas[i] + "'"
 	}
 	query += ") AND table_type IN ('BASE TABLE', 'VIEW')"
+	// keep a stable order, so snapshots of the same schema are identical
+	query += " ORDER BY table_schema, table_name"
 	// fmt.Printf("Query: %s\n", query)
 
 	rows, err := conn.Query(context.Background(), query)
@@ -111,7 +83,7 @@ func readPgTables(conn *pgx.Conn, schemas []string) ([]metadata.Table, error) {
 	var tables []metadata.Table
 	for rows.Next() {
 		var table metadata.Table
-		err := rows.Scan(&table.Schema, &table.Name)
+		err := rows.Scan(&table.Schema, &table.Name, &table.Comment)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan table list row: %w", err)
 		}
@@ -125,9 +97,21 @@ func readPgTables(conn *pgx.Conn, schemas []string) ([]metadata.Table, error) {
 	return tables, nil
 }
 
+// readPgColumns reads the columns of the tables. Base types are formatted
+// with their modifiers by format_type, arrays formatting their element type.
 func readPgColumns(conn *pgx.Conn, schemas []string) (map[string][]metadata.Column, error) {
 	var query = `
-		SELECT ordinal_position, table_schema, table_name, column_name, data_type, is_nullable, column_default
+		SELECT ordinal_position, table_schema, table_name, column_name, data_type, is_nullable, column_default,
+		       udt_schema, udt_name,
+		       COALESCE(col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position), ''),
+		       COALESCE((
+		           SELECT format_type(et.oid, a.atttypmod)
+		           FROM pg_attribute a
+		           JOIN pg_type t ON t.oid = a.atttypid
+		           JOIN pg_type et ON et.oid = CASE WHEN t.typcategory = 'A' THEN t.typelem ELSE t.oid END
+		           WHERE a.attrelid = format('%I.%I', table_schema, table_name)::regclass
+		             AND a.attname = column_name AND et.typtype = 'b'
+		       ), '')
 		FROM information_schema.columns WHERE table_schema IN (
 	`
 	for i := 0; i < len(schemas); i++ {
@@ -159,7 +143,11 @@ func readPgColumns(conn *pgx.Conn, schemas []string) (map[string][]metadata.Colu
 			&column.Name,
 			&column.Datatype,
 			&nullable,
-			&column.DefaultValue)
+			&column.DefaultValue,
+			&column.UdtSchema,
+			&column.UdtName,
+			&column.Comment,
+			&column.FormattedType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan columns list row: %w", err)
 		}
@@ -177,12 +165,39 @@ func readPgColumns(conn *pgx.Conn, schemas []string) (map[string][]metadata.Colu
 	return columnMap, nil
 }
 
-func readPgConstraints(conn *pgx.Conn, schemas []string) ([]PgConstraints, error) {
+// readPgConstraints reads primary key, unique and foreign key constraints
+// from pg_constraint, whose conkey and confkey arrays keep the columns of a
+// key and the ones they reference in order and paired up
+func readPgConstraints(conn *pgx.Conn, schemas []string) ([]PgConstraint, error) {
 	var query = `
-		SELECT kcu.table_schema, kcu.table_name, kcu.column_name, kcu.constraint_name, tc.constraint_type
-		FROM information_schema.key_column_usage kcu
-			INNER JOIN information_schema.table_constraints tc ON kcu.constraint_name = tc.constraint_name
-		WHERE kcu.table_schema IN (
+		SELECT
+			n.nspname,
+			c.relname,
+			con.conname,
+			con.contype::text,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+					INNER JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			) AS columns,
+			COALESCE(rn.nspname::text, '') AS referenced_schema,
+			COALESCE(rc.relname::text, '') AS referenced_table,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+					INNER JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			) AS referenced_columns,
+			con.confdeltype::text,
+			con.confupdtype::text
+		FROM pg_constraint con
+			INNER JOIN pg_class c ON c.oid = con.conrelid
+			INNER JOIN pg_namespace n ON n.oid = c.relnamespace
+			LEFT JOIN pg_class rc ON rc.oid = con.confrelid
+			LEFT JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.contype IN ('p', 'u', 'f')
+		  AND n.nspname IN (
 	`
 	for i := 0; i < len(schemas); i++ {
 		if i > 0 {
@@ -190,7 +205,7 @@ func readPgConstraints(conn *pgx.Conn, schemas []string) ([]PgConstraints, error
 		}
 		query += "'" + schemas[i] + "'"
 	}
-	query += ")"
+	query += ") ORDER BY n.nspname, c.relname, position(con.contype::text IN 'puf'), con.conname"
 	// fmt.Printf("Query: %s\n", query)
 
 	rows, err := conn.Query(context.Background(), query)
@@ -199,18 +214,31 @@ func readPgConstraints(conn *pgx.Conn, schemas []string) ([]PgConstraints, error
 	}
 	defer rows.Close()
 
-	var constraints = make([]PgConstraints, 0)
+	var constraints = make([]PgConstraint, 0)
 	for rows.Next() {
-		var constraint PgConstraints
+		var constraint PgConstraint
+		var contype, deleteAction, updateAction string
 		err := rows.Scan(
 			&constraint.Schema,
 			&constraint.Table,
-			&constraint.Column,
-			&constraint.Name,
-			&constraint.Type)
+			&constraint.Constraint.Name,
+			&contype,
+			&constraint.Constraint.Columns,
+			&constraint.Constraint.RefSchema,
+			&constraint.Constraint.RefTable,
+			&constraint.Constraint.RefColumns,
+			&deleteAction,
+			&updateAction)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan constraint list row: %w", err)
 		}
+		constraint.Constraint.Type = pgConstraintTypes[contype]
+		if constraint.Constraint.Type == metadata.ForeignKeyConstraint {
+			constraint.Constraint.OnDelete = pgReferentialActions[deleteAction]
+			constraint.Constraint.OnUpdate = pgReferentialActions[updateAction]
+		} else {
+			constraint.Constraint.RefColumns = nil
+		}
 		constraints = append(constraints, constraint)
 	}
 
@@ -221,21 +249,19 @@ func readPgConstraints(conn *pgx.Conn, schemas []string) ([]PgConstraints, error
 	return constraints, nil
 }
 
-func readFkInfo(conn *pgx.Conn, schemas []string) ([]PgFkInfo, error) {
+func readAutoIncrementInfo(conn *pgx.Conn, schemas []string) ([]PgAutoIncrementInfo, error) {
 	var query = `
 		SELECT
-			tc.constraint_name,
-			tc.table_schema AS fk_schema,
-			tc.table_name AS fk_table,
-			kcu.column_name AS fk_column,
-			ccu.table_schema AS referenced_schema,
-			ccu.table_name AS referenced_table,
-			ccu.column_name AS referenced_column
-		FROM information_schema.table_constraints AS tc
-				 JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name
-				 JOIN information_schema.constraint_column_usage AS ccu ON ccu.constraint_name = tc.constraint_name
-		WHERE tc.constraint_type = 'FOREIGN KEY'
-		  AND tc.table_schema in (
+			c.table_schema,
+			c.table_name,
+			c.column_name,
+			CASE
+				WHEN pg_get_serial_sequence(format('%I.%I', c.table_schema, c.table_name), c.column_name) IS NOT NULL THEN 'SERIAL'
+				WHEN c.is_identity = 'YES' THEN 'IDENTITY'
+				ELSE 'NOT AUTO INCREMENT'
+			END AS auto_increment_type
+		FROM information_schema.columns c
+		WHERE c.table_schema IN (
 	`
 	for i := 0; i < len(schemas); i++ {
 		if i > 0 {
@@ -248,47 +274,51 @@ func readFkInfo(conn *pgx.Conn, schemas []string) ([]PgFkInfo, error) {
 
 	rows, err := conn.Query(context.Background(), query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query fk list: %w", err)
+		return nil, fmt.Errorf("failed to query auto increment info list: %w", err)
 	}
 	defer rows.Close()
 
-	var fkInfos = make([]PgFkInfo, 0)
+	var aiInfos = make([]PgAutoIncrementInfo, 0)
 	for rows.Next() {
-		var fkInfo PgFkInfo
+		var aiInfo PgAutoIncrementInfo
 		err := rows.Scan(
-			&fkInfo.ConstraintName,
-			&fkInfo.FkSchema,
-			&fkInfo.FkTable,
-			&fkInfo.FkColumn,
-			&fkInfo.ReferencedSchema,
-			&fkInfo.ReferencedTable,
-			&fkInfo.ReferencedColumn)
+			&aiInfo.Schema,
+			&aiInfo.Table,
+			&aiInfo.Column,
+			&aiInfo.IncrementType)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan fk list row: %w", err)
+			return nil, fmt.Errorf("failed to scan auto increment info list row: %w", err)
 		}
-		fkInfos = append(fkInfos, fkInfo)
+		aiInfos = append(aiInfos, aiInfo)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over fk list rows: %w", err)
+		return nil, fmt.Errorf("error iterating over auto increment info list rows: %w", err)
 	}
 
-	return fkInfos, nil
+	return aiInfos, nil
 }
 
-func readAutoIncrementInfo(conn *pgx.Conn, schemas []string) ([]PgAutoIncrementInfo, error) {
+type PgArrayInfo struct {
+	Schema      string
+	Table       string
+	Column      string
+	Dims        int
+	ElementType string
+}
+
+// readPgArrayInfo reads the element type and dimensions of array columns.
+// Element types are named as information_schema.columns.data_type would.
+func readPgArrayInfo(conn *pgx.Conn, schemas []string) ([]PgArrayInfo, error) {
 	var query = `
-		SELECT
-			c.table_schema,
-			c.table_name,
-			c.column_name,
-			CASE
-				WHEN pg_get_serial_sequence(format('%I.%I', c.table_schema, c.table_name), c.column_name) IS NOT NULL THEN 'SERIAL'
-				WHEN c.is_identity = 'YES' THEN 'IDENTITY'
-				ELSE 'NOT AUTO INCREMENT'
-			END AS auto_increment_type
-		FROM information_schema.columns c
-		WHERE c.table_schema IN (
+		SELECT n.nspname, c.relname, a.attname, a.attndims,
+		       CASE WHEN et.typtype = 'e' THEN 'USER-DEFINED' ELSE format_type(et.oid, NULL) END
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_type t ON t.oid = a.atttypid
+		JOIN pg_type et ON et.oid = t.typelem
+		WHERE t.typcategory = 'A' AND a.attnum > 0 AND NOT a.attisdropped AND n.nspname IN (
 	`
 	for i := 0; i < len(schemas); i++ {
 		if i > 0 {
@@ -297,33 +327,147 @@ func readAutoIncrementInfo(conn *pgx.Conn, schemas []string) ([]PgAutoIncrementI
 		query += "'" + schemas[i] + "'"
 	}
 	query += ")"
+
+	rows, err := conn.Query(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query array column list: %w", err)
+	}
+	defer rows.Close()
+
+	arrayInfos := make([]PgArrayInfo, 0)
+	for rows.Next() {
+		var info PgArrayInfo
+		err := rows.Scan(&info.Schema, &info.Table, &info.Column, &info.Dims, &info.ElementType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan array column list row: %w", err)
+		}
+		// attndims is 0 for arrays whose dimensions were never declared, as in CREATE TABLE AS
+		if info.Dims < 1 {
+			info.Dims = 1
+		}
+		arrayInfos = append(arrayInfos, info)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over array column list rows: %w", err)
+	}
+
+	return arrayInfos, nil
+}
+
+// readPgEnums reads the labels of the enum types defined in the given schemas
+// and of those used by columns, wherever they are defined
+func readPgEnums(conn *pgx.Conn, schemas []string, columnsMap map[string][]metadata.Column) ([]metadata.Enum, error) {
+	var query = `
+		SELECT n.nspname, t.typname, e.enumlabel
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY n.nspname, t.typname, e.enumsortorder
+	`
+
+	rows, err := conn.Query(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enum list: %w", err)
+	}
+	defer rows.Close()
+
+	used := make(map[string]bool)
+	for _, columns := range columnsMap {
+		for i := range columns {
+			if columns[i].Datatype == "USER-DEFINED" || columns[i].IsArray() {
+				used[columns[i].UdtSchema+"."+columns[i].ElementUdtName()] = true
+			}
+		}
+	}
+
+	enums := make([]metadata.Enum, 0)
+	for rows.Next() {
+		var schema, name, label string
+		err := rows.Scan(&schema, &name, &label)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan enum list row: %w", err)
+		}
+		if !metadata.ContainsString(schemas, schema) && !used[schema+"."+name] {
+			continue
+		}
+		if len(enums) == 0 || enums[len(enums)-1].Schema != schema || enums[len(enums)-1].Name != name {
+			enums = append(enums, metadata.Enum{Schema: schema, Name: name, Labels: make([]string, 0)})
+		}
+		enums[len(enums)-1].Labels = append(enums[len(enums)-1].Labels, label)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over enum list rows: %w", err)
+	}
+
+	return enums, nil
+}
+
+// readPgIndexes reads the indexes of the tables with their key columns in
+// order, INCLUDE columns left out. Partial and expression indexes are
+// skipped, as are the ones not valid yet.
+func readPgIndexes(conn *pgx.Conn, schemas []string) ([]PgIndex, error) {
+	var query = `
+		SELECT
+			n.nspname,
+			c.relname,
+			i.relname,
+			ix.indisunique,
+			ix.indisprimary,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+					INNER JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+				WHERE k.ord <= ix.indnkeyatts
+				ORDER BY k.ord
+			) AS columns
+		FROM pg_index ix
+			INNER JOIN pg_class c ON c.oid = ix.indrelid
+			INNER JOIN pg_class i ON i.oid = ix.indexrelid
+			INNER JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE ix.indisvalid
+		  AND ix.indpred IS NULL
+		  AND NOT (0 = ANY(ix.indkey::int2[]))
+		  AND n.nspname IN (
+	`
+	for i := 0; i < len(schemas); i++ {
+		if i > 0 {
+			query += ", "
+		}
+		query += "'" + schemas[i] + "'"
+	}
+	query += ") ORDER BY n.nspname, c.relname, ix.indisprimary DESC, i.relname"
 	// fmt.Printf("Query: %s\n", query)
 
 	rows, err := conn.Query(context.Background(), query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query auto increment info list: %w", err)
+		return nil, fmt.Errorf("failed to query index list: %w", err)
 	}
 	defer rows.Close()
 
-	var aiInfos = make([]PgAutoIncrementInfo, 0)
+	var indexes = make([]PgIndex, 0)
 	for rows.Next() {
-		var aiInfo PgAutoIncrementInfo
+		var index PgIndex
 		err := rows.Scan(
-			&aiInfo.Schema,
-			&aiInfo.Table,
-			&aiInfo.Column,
-			&aiInfo.IncrementType)
+			&index.Schema,
+			&index.Table,
+			&index.Index.Name,
+			&index.Index.IsUnique,
+			&index.Index.IsPrimary,
+			&index.Index.Columns)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan auto increment info list row: %w", err)
+			return nil, fmt.Errorf("failed to scan index list row: %w", err)
 		}
-		aiInfos = append(aiInfos, aiInfo)
+		indexes = append(indexes, index)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over auto increment info list rows: %w", err)
+		return nil, fmt.Errorf("error iterating over index list rows: %w", err)
 	}
 
-	return aiInfos, nil
+	return indexes, nil
 }
 
 func ReadPostgresMetadata(config config.Config) (*metadata.Metadata, error) {
@@ -355,16 +499,28 @@ func ReadPostgresMetadata(config config.Config) (*metadata.Metadata, error) {
 		}
 	}
 
+	// read element types of array columns
+	pgArrayInfos, err := readPgArrayInfo(conn, config.ConnInfo.Schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read array column list: %w", err)
+	}
+
+	// read enum types
+	enums, err := readPgEnums(conn, config.ConnInfo.Schemas, columnsMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enum list: %w", err)
+	}
+
 	// read constraints
 	constraints, err := readPgConstraints(conn, config.ConnInfo.Schemas)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read constraint list: %w", err)
 	}
 
-	// read foreign key references
-	pgFkInfos, err := readFkInfo(conn, config.ConnInfo.Schemas)
+	// read indexes
+	pgIndexes, err := readPgIndexes(conn, config.ConnInfo.Schemas)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read fk list: %w", err)
+		return nil, fmt.Errorf("failed to read index list: %w", err)
 	}
 
 	// read auto increment info
@@ -373,32 +529,45 @@ func ReadPostgresMetadata(config config.Config) (*metadata.Metadata, error) {
 		return nil, fmt.Errorf("failed to read auto increment list: %w", err)
 	}
 
-	// mark primary keys and foreign keys
+	// attach constraints, marking primary keys and single column foreign keys
 	for i := range tables {
+		for k := range constraints {
+			if constraints[k].Schema != tables[i].Schema || constraints[k].Table != tables[i].Name {
+				continue
+			}
+			constraint := constraints[k].Constraint
+			tables[i].Constraints = append(tables[i].Constraints, constraint)
+			for l := range constraint.Columns {
+				col := tables[i].SearchColumnByName(constraint.Columns[l])
+				if col == nil {
+					continue
+				}
+				if constraint.Type == metadata.PrimaryKeyConstraint {
+					col.IsPrimaryKey = true
+				} else if constraint.Type == metadata.ForeignKeyConstraint && len(constraint.Columns) == 1 {
+					col.FkTarget = &metadata.ForeignKeyTarget{
+						Schema: constraint.RefSchema,
+						Table:  constraint.RefTable,
+						Column: constraint.RefColumns[0],
+					}
+				}
+			}
+		}
+
+		for k := range pgIndexes {
+			if pgIndexes[k].Schema == tables[i].Schema && pgIndexes[k].Table == tables[i].Name {
+				tables[i].Indexes = append(tables[i].Indexes, pgIndexes[k].Index)
+			}
+		}
+
 		cols := tables[i].Columns
 		for j := range cols {
-			for k := range constraints {
-				if constraints[k].Schema == tables[i].Schema && constraints[k].Table == tables[i].Name {
-					if constraints[k].Column == cols[j].Name && constraints[k].Type == "PRIMARY KEY" {
-						cols[j].IsPrimaryKey = true
-					} else if constraints[k].Column == cols[j].Name && constraints[k].Type == "FOREIGN KEY" {
-						var idx = -1
-						for l := range pgFkInfos {
-							if pgFkInfos[l].FkSchema == tables[i].Schema &&
-								pgFkInfos[l].FkTable == tables[i].Name &&
-								pgFkInfos[l].FkColumn == cols[j].Name {
-								idx = l
-							}
-						}
-						if idx > -1 {
-							var fkTarget = metadata.ForeignKeyTarget{
-								Schema: pgFkInfos[idx].ReferencedSchema,
-								Table:  pgFkInfos[idx].ReferencedTable,
-								Column: pgFkInfos[idx].ReferencedColumn,
-							}
-							cols[j].FkTarget = &fkTarget
-						}
-					}
+			for k := range pgArrayInfos {
+				if pgArrayInfos[k].Schema == tables[i].Schema &&
+					pgArrayInfos[k].Table == tables[i].Name &&
+					pgArrayInfos[k].Column == cols[j].Name {
+					cols[j].ElementType = pgArrayInfos[k].ElementType
+					cols[j].ArrayDims = pgArrayInfos[k].Dims
 				}
 			}
 
@@ -416,5 +585,6 @@ func ReadPostgresMetadata(config config.Config) (*metadata.Metadata, error) {
 	return &metadata.Metadata{
 		Database: config.ConnInfo.Database,
 		Tables:   tables,
+		Enums:    enums,
 	}, nil
 }
@@ -0,0 +1,112 @@
+package pgsql
+
+import (
+	"dto-gen/metadata"
+)
+
+// PgType describes how a PostgreSQL type is represented by the generated
+// code. Types are identified by their udt_name ("int4", "timestamptz"), the
+// display name being the one information_schema reports as data_type.
+// Nullable columns get a pointer to GoType in go and Optional[PythonType]
+// in python.
+type PgType struct {
+	UdtName     string
+	DisplayName string
+	GoType      string
+	PythonType  string
+	NoEquality  bool // values can't be compared with =
+}
+
+var PostgreSQLTypes = []PgType{
+	// bit strings may be longer than one bit, psycopg2 reads them as '0101'
+	{UdtName: "bit", DisplayName: "bit", GoType: "pgtype.Bits", PythonType: "str"},
+	{UdtName: "bool", DisplayName: "boolean", GoType: "bool", PythonType: "bool"},
+	{UdtName: "bpchar", DisplayName: "character", GoType: "string", PythonType: "str"},
+	{UdtName: "bytea", DisplayName: "bytea", GoType: "[]byte", PythonType: "bytes"},
+	{UdtName: "cidr", DisplayName: "cidr", GoType: "netip.Prefix", PythonType: "str"},
+	{UdtName: "date", DisplayName: "date", GoType: "time.Time", PythonType: "datetime.date"},
+	{UdtName: "daterange", DisplayName: "daterange", GoType: "pgtype.Range[pgtype.Date]", PythonType: "psycopg2.extras.DateRange"},
+	{UdtName: "float4", DisplayName: "real", GoType: "float32", PythonType: "float"},
+	{UdtName: "float8", DisplayName: "double precision", GoType: "float64", PythonType: "float"},
+	{UdtName: "inet", DisplayName: "inet", GoType: "netip.Prefix", PythonType: "str"},
+	{UdtName: "int2", DisplayName: "smallint", GoType: "int16", PythonType: "int"},
+	{UdtName: "int4", DisplayName: "integer", GoType: "int", PythonType: "int"},
+	{UdtName: "int4range", DisplayName: "int4range", GoType: "pgtype.Range[pgtype.Int4]", PythonType: "psycopg2.extras.NumericRange"},
+	{UdtName: "int8", DisplayName: "bigint", GoType: "int64", PythonType: "int"},
+	{UdtName: "int8range", DisplayName: "int8range", GoType: "pgtype.Range[pgtype.Int8]", PythonType: "psycopg2.extras.NumericRange"},
+	{UdtName: "interval", DisplayName: "interval", GoType: "time.Duration", PythonType: "datetime.timedelta"},
+	{UdtName: "json", DisplayName: "json", GoType: "json.RawMessage", PythonType: "dict | list", NoEquality: true},
+	{UdtName: "jsonb", DisplayName: "jsonb", GoType: "json.RawMessage", PythonType: "dict | list"},
+	{UdtName: "macaddr", DisplayName: "macaddr", GoType: "net.HardwareAddr", PythonType: "str"},
+	{UdtName: "macaddr8", DisplayName: "macaddr8", GoType: "net.HardwareAddr", PythonType: "str"},
+	// money and numeric are scanned as text, so no precision is lost
+	{UdtName: "money", DisplayName: "money", GoType: "string", PythonType: "str"},
+	{UdtName: "name", DisplayName: "name", GoType: "string", PythonType: "str"},
+	{UdtName: "numeric", DisplayName: "numeric", GoType: "string", PythonType: "decimal.Decimal"},
+	{UdtName: "numrange", DisplayName: "numrange", GoType: "pgtype.Range[pgtype.Numeric]", PythonType: "psycopg2.extras.NumericRange"},
+	{UdtName: "oid", DisplayName: "oid", GoType: "uint32", PythonType: "int"},
+	{UdtName: "point", DisplayName: "point", GoType: "pgtype.Point", PythonType: "str", NoEquality: true},
+	{UdtName: "text", DisplayName: "text", GoType: "string", PythonType: "str"},
+	{UdtName: "time", DisplayName: "time without time zone", GoType: "string", PythonType: "datetime.time"},
+	{UdtName: "timestamp", DisplayName: "timestamp without time zone", GoType: "time.Time", PythonType: "datetime.datetime"},
+	{UdtName: "timestamptz", DisplayName: "timestamp with time zone", GoType: "time.Time", PythonType: "datetime.datetime"},
+	{UdtName: "tsrange", DisplayName: "tsrange", GoType: "pgtype.Range[pgtype.Timestamp]", PythonType: "psycopg2.extras.DateTimeRange"},
+	{UdtName: "tstzrange", DisplayName: "tstzrange", GoType: "pgtype.Range[pgtype.Timestamptz]", PythonType: "psycopg2.extras.DateTimeTZRange"},
+	{UdtName: "timetz", DisplayName: "time with time zone", GoType: "string", PythonType: "datetime.time"},
+	{UdtName: "tsquery", DisplayName: "tsquery", GoType: "string", PythonType: "str"},
+	{UdtName: "tsvector", DisplayName: "tsvector", GoType: "string", PythonType: "str"},
+	{UdtName: "uuid", DisplayName: "uuid", GoType: "string", PythonType: "str"},
+	{UdtName: "varbit", DisplayName: "bit varying", GoType: "pgtype.Bits", PythonType: "str"},
+	{UdtName: "varchar", DisplayName: "character varying", GoType: "string", PythonType: "str"},
+	{UdtName: "xml", DisplayName: "xml", GoType: "string", PythonType: "str", NoEquality: true},
+}
+
+// PostgreSQLToGolangTypes maps udt names to go types
+var PostgreSQLToGolangTypes = func() map[string]string {
+	types := make(map[string]string)
+	for i := range PostgreSQLTypes {
+		types[PostgreSQLTypes[i].UdtName] = PostgreSQLTypes[i].GoType
+	}
+	return types
+}()
+
+// PostgreSQLToPythonTypes maps udt names to python type hints
+var PostgreSQLToPythonTypes = func() map[string]string {
+	types := make(map[string]string)
+	for i := range PostgreSQLTypes {
+		types[PostgreSQLTypes[i].UdtName] = PostgreSQLTypes[i].PythonType
+	}
+	return types
+}()
+
+// SearchPgType looks a type up by its udt name or its display name
+func SearchPgType(name string) *PgType {
+	for i := range PostgreSQLTypes {
+		if PostgreSQLTypes[i].UdtName == name || PostgreSQLTypes[i].DisplayName == name {
+			return &PostgreSQLTypes[i]
+		}
+	}
+	return nil
+}
+
+// UdtNameOf returns the udt name of a type given by its udt or display
+// name, or the name itself for types we don't know about
+func UdtNameOf(name string) string {
+	if pgType := SearchPgType(name); pgType != nil {
+		return pgType.UdtName
+	}
+	return name
+}
+
+// ColumnUdtName returns the udt name of the type of a column, or of its
+// elements for arrays. Metadata read before udt names were recorded only
+// knows the display name of builtin types.
+func ColumnUdtName(col *metadata.Column) string {
+	if udtName := col.ElementUdtName(); udtName != "" {
+		return udtName
+	}
+	if col.IsArray() {
+		return UdtNameOf(col.ElementType)
+	}
+	return UdtNameOf(col.Datatype)
+}
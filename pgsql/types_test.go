@@ -0,0 +1,144 @@
+package pgsql
+
+import (
+	"dto-gen/metadata"
+	"testing"
+)
+
+func TestSearchPgType(t *testing.T) {
+	tests := []struct {
+		name    string
+		udtName string
+	}{
+		{"int4", "int4"},
+		{"integer", "int4"},
+		{"bpchar", "bpchar"},
+		{"character", "bpchar"},
+		{"character varying", "varchar"},
+		{"timestamp with time zone", "timestamptz"},
+		{"time without time zone", "time"},
+		{"bit", "bit"},
+		{"varbit", "varbit"},
+		{"bit varying", "varbit"},
+		{"int4range", "int4range"},
+		{"tstzrange", "tstzrange"},
+		{"box", ""},
+		{"mood", ""},
+	}
+	for _, tt := range tests {
+		pgType := SearchPgType(tt.name)
+		if tt.udtName == "" {
+			if pgType != nil {
+				t.Errorf("SearchPgType(%q) = %q, want none", tt.name, pgType.UdtName)
+			}
+			continue
+		}
+		if pgType == nil {
+			t.Errorf("SearchPgType(%q) = none, want %q", tt.name, tt.udtName)
+		} else if pgType.UdtName != tt.udtName {
+			t.Errorf("SearchPgType(%q) = %q, want %q", tt.name, pgType.UdtName, tt.udtName)
+		}
+	}
+}
+
+func TestPgTypeNamesAreUnique(t *testing.T) {
+	seen := make(map[string]string)
+	for _, pgType := range PostgreSQLTypes {
+		for _, name := range []string{pgType.UdtName, pgType.DisplayName} {
+			if other, exists := seen[name]; exists && other != pgType.UdtName {
+				t.Errorf("%q names both %s and %s", name, other, pgType.UdtName)
+			}
+			seen[name] = pgType.UdtName
+		}
+	}
+}
+
+func TestPostgreSQLToGolangTypes(t *testing.T) {
+	tests := []struct {
+		udtName string
+		goType  string
+	}{
+		{"bool", "bool"},
+		{"bit", "pgtype.Bits"},
+		{"varbit", "pgtype.Bits"},
+		{"int4", "int"},
+		{"numeric", "string"},
+		{"timestamptz", "time.Time"},
+		{"inet", "netip.Prefix"},
+		{"jsonb", "json.RawMessage"},
+		{"int4range", "pgtype.Range[pgtype.Int4]"},
+		{"int8range", "pgtype.Range[pgtype.Int8]"},
+		{"numrange", "pgtype.Range[pgtype.Numeric]"},
+		{"daterange", "pgtype.Range[pgtype.Date]"},
+		{"tsrange", "pgtype.Range[pgtype.Timestamp]"},
+		{"tstzrange", "pgtype.Range[pgtype.Timestamptz]"},
+	}
+	for _, tt := range tests {
+		if got := PostgreSQLToGolangTypes[tt.udtName]; got != tt.goType {
+			t.Errorf("go type of %s = %q, want %q", tt.udtName, got, tt.goType)
+		}
+	}
+}
+
+func TestPostgreSQLToPythonTypes(t *testing.T) {
+	tests := []struct {
+		udtName    string
+		pythonType string
+	}{
+		{"bool", "bool"},
+		{"bit", "str"},
+		{"varbit", "str"},
+		{"numeric", "decimal.Decimal"},
+		{"date", "datetime.date"},
+		{"json", "dict | list"},
+		{"int4range", "psycopg2.extras.NumericRange"},
+		{"numrange", "psycopg2.extras.NumericRange"},
+		{"daterange", "psycopg2.extras.DateRange"},
+		{"tsrange", "psycopg2.extras.DateTimeRange"},
+		{"tstzrange", "psycopg2.extras.DateTimeTZRange"},
+	}
+	for _, tt := range tests {
+		if got := PostgreSQLToPythonTypes[tt.udtName]; got != tt.pythonType {
+			t.Errorf("python type of %s = %q, want %q", tt.udtName, got, tt.pythonType)
+		}
+	}
+}
+
+func TestUdtNameOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		udtName string
+	}{
+		{"double precision", "float8"},
+		{"float8", "float8"},
+		{"bit varying", "varbit"},
+		{"daterange", "daterange"},
+		{"USER-DEFINED", "USER-DEFINED"},
+		{"box", "box"},
+	}
+	for _, tt := range tests {
+		if got := UdtNameOf(tt.name); got != tt.udtName {
+			t.Errorf("UdtNameOf(%q) = %q, want %q", tt.name, got, tt.udtName)
+		}
+	}
+}
+
+func TestColumnUdtName(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     metadata.Column
+		udtName string
+	}{
+		{"udt name", metadata.Column{Datatype: "integer", UdtName: "int4"}, "int4"},
+		{"array udt name", metadata.Column{Datatype: "ARRAY", ElementType: "integer", UdtName: "_int4"}, "int4"},
+		{"enum", metadata.Column{Datatype: "USER-DEFINED", UdtName: "mood"}, "mood"},
+		{"display name only", metadata.Column{Datatype: "timestamp with time zone"}, "timestamptz"},
+		{"array display name only", metadata.Column{Datatype: "ARRAY", ElementType: "bit varying"}, "varbit"},
+		{"unknown", metadata.Column{Datatype: "box"}, "box"},
+	}
+	for _, tt := range tests {
+		if got := ColumnUdtName(&tt.col); got != tt.udtName {
+			t.Errorf("%s: ColumnUdtName() = %q, want %q", tt.name, got, tt.udtName)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package sqlite
+
+import (
+	"database/sql"
+	"dto-gen/config"
+	"dto-gen/metadata"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteToGolangTypes maps normalized declared types to go types. Decimal
+// and numeric columns, those of numeric affinity included, are read into
+// strings, as decimals are on PostgreSQL and MySQL. Json documents are stored
+// as text and unmarshalled into json.RawMessage, or into the go type the
+// config binds their column to.
+var SQLiteToGolangTypes = map[string]string{
+	"bigint":            "int64",
+	"blob":              "[]byte",
+	"boolean":           "bool",
+	"char":              "string",
+	"character":         "string",
+	"clob":              "string",
+	"date":              "time.Time",
+	"datetime":          "time.Time",
+	"decimal":           "string",
+	"double":            "float64",
+	"double precision":  "float64",
+	"float":             "float64",
+	"int":               "int64",
+	"integer":           "int64",
+	"json":              "json.RawMessage",
+	"mediumint":         "int64",
+	"nchar":             "string",
+	"numeric":           "string",
+	"nvarchar":          "string",
+	"real":              "float64",
+	"smallint":          "int64",
+	"text":              "string",
+	"timestamp":         "time.Time",
+	"tinyint":           "int64",
+	"varchar":           "string",
+	"varying character": "string",
+}
+
+var SQLiteToPythonTypes = map[string]string{
+	"bigint":            "int",
+	"blob":              "bytes",
+	"boolean":           "bool",
+	"char":              "str",
+	"character":         "str",
+	"clob":              "str",
+	"date":              "datetime.date",
+	"datetime":          "datetime.datetime",
+	"decimal":           "decimal.Decimal",
+	"double":            "float",
+	"double precision":  "float",
+	"float":             "float",
+	"int":               "int",
+	"integer":           "int",
+	"json":              "str",
+	"mediumint":         "int",
+	"nchar":             "str",
+	"numeric":           "decimal.Decimal",
+	"nvarchar":          "str",
+	"real":              "float",
+	"smallint":          "int",
+	"text":              "str",
+	"timestamp":         "datetime.datetime",
+	"tinyint":           "int",
+	"varchar":           "str",
+	"varying character": "str",
+}
+
+type SqliteColumnInfo struct {
+	Cid          int
+	Name         string
+	Type         string
+	NotNull      bool
+	DefaultValue *string
+	PkIndex      int
+}
+
+type SqliteIndexInfo struct {
+	Name    string
+	Unique  bool
+	Origin  string
+	Partial bool
+	Columns []*string
+}
+
+type SqliteFkInfo struct {
+	Id               int
+	Seq              int
+	ReferencedTable  string
+	FkColumn         string
+	ReferencedColumn *string
+	OnUpdate         string
+	OnDelete         string
+}
+
+func connectToSQLite(connInfo config.ConnectionInfo) (*sql.DB, error) {
+	fmt.Printf("Database File: %s\n", connInfo.Database)
+
+	db, err := sql.Open("sqlite", connInfo.Database)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// normalizeType turns a declared column type such as "VARCHAR(40)" into a key
+// of the type maps, falling back to SQLite's type affinity rules for
+// declared types we don't know about.
+func normalizeType(declared string) string {
+	datatype := strings.ToLower(strings.TrimSpace(declared))
+	if idx := strings.Index(datatype, "("); idx >= 0 {
+		datatype = strings.TrimSpace(datatype[:idx])
+	}
+	if _, exists := SQLiteToGolangTypes[datatype]; exists {
+		return datatype
+	}
+	// JSONB columns hold SQLite's binary encoding, other json types text
+	if strings.Contains(datatype, "jsonb") {
+		return "blob"
+	}
+	if strings.Contains(datatype, "json") {
+		return "json"
+	}
+
+	// https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+	switch {
+	case strings.Contains(datatype, "int"):
+		return "integer"
+	case strings.Contains(datatype, "char"), strings.Contains(datatype, "clob"), strings.Contains(datatype, "text"):
+		return "text"
+	case datatype == "", strings.Contains(datatype, "blob"):
+		return "blob"
+	case strings.Contains(datatype, "real"), strings.Contains(datatype, "floa"), strings.Contains(datatype, "doub"):
+		return "real"
+	}
+	return "numeric"
+}
+
+func readSQLiteTables(db *sql.DB) ([]metadata.Table, []string, error) {
+	var query = `
+		SELECT name, COALESCE(sql, '')
+		FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query table list: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []metadata.Table
+	var ddls []string
+	for rows.Next() {
+		var table metadata.Table
+		var ddl string
+		err := rows.Scan(&table.Name, &ddl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan table list row: %w", err)
+		}
+		table.Schema = "main"
+		tables = append(tables, table)
+		ddls = append(ddls, ddl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating over table list rows: %w", err)
+	}
+
+	return tables, ddls, nil
+}
+
+func readSQLiteColumns(db *sql.DB, table string) ([]SqliteColumnInfo, error) {
+	rows, err := db.Query("SELECT cid, name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns list: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []SqliteColumnInfo
+	for rows.Next() {
+		var column SqliteColumnInfo
+		err := rows.Scan(
+			&column.Cid,
+			&column.Name,
+			&column.Type,
+			&column.NotNull,
+			&column.DefaultValue,
+			&column.PkIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan columns list row: %w", err)
+		}
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over columns list rows: %w", err)
+	}
+
+	return columns, nil
+}
+
+func readSQLiteFkInfo(db *sql.DB, table string) ([]SqliteFkInfo, error) {
+	rows, err := db.Query("SELECT id, seq, \"table\", \"from\", \"to\", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id DESC, seq", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fk list: %w", err)
+	}
+	defer rows.Close()
+
+	var fkInfos = make([]SqliteFkInfo, 0)
+	for rows.Next() {
+		var fkInfo SqliteFkInfo
+		err := rows.Scan(
+			&fkInfo.Id,
+			&fkInfo.Seq,
+			&fkInfo.ReferencedTable,
+			&fkInfo.FkColumn,
+			&fkInfo.ReferencedColumn,
+			&fkInfo.OnUpdate,
+			&fkInfo.OnDelete)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fk list row: %w", err)
+		}
+		fkInfos = append(fkInfos, fkInfo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over fk list rows: %w", err)
+	}
+
+	return fkInfos, nil
+}
+
+// readSQLiteIndexes reads the indexes of a table in creation order, those
+// sqlite creates for PRIMARY KEY and UNIQUE constraints included
+func readSQLiteIndexes(db *sql.DB, table string) ([]SqliteIndexInfo, error) {
+	rows, err := db.Query("SELECT name, \"unique\", origin, partial FROM pragma_index_list(?) ORDER BY seq DESC", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index list: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []SqliteIndexInfo
+	for rows.Next() {
+		var index SqliteIndexInfo
+		err := rows.Scan(
+			&index.Name,
+			&index.Unique,
+			&index.Origin,
+			&index.Partial)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan index list row: %w", err)
+		}
+		indexes = append(indexes, index)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over index list rows: %w", err)
+	}
+
+	for i := range indexes {
+		indexes[i].Columns, err = readSQLiteIndexColumns(db, indexes[i].Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+// readSQLiteIndexColumns reads the key columns of an index in order, nil
+// standing for an expression
+func readSQLiteIndexColumns(db *sql.DB, index string) ([]*string, error) {
+	rows, err := db.Query("SELECT name FROM pragma_index_info(?) ORDER BY seqno", index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index columns of %s: %w", index, err)
+	}
+	defer rows.Close()
+
+	var columns []*string
+	for rows.Next() {
+		var column *string
+		err := rows.Scan(&column)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan index columns row: %w", err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over index columns rows: %w", err)
+	}
+	return columns, nil
+}
+
+func ReadSQLiteMetadata(config config.Config) (*metadata.Metadata, error) {
+	db, err := connectToSQLite(config.ConnInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// read tables
+	tables, ddls, err := readSQLiteTables(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table list: %w", err)
+	}
+
+	for i := range tables {
+		// read columns
+		columnInfos, err := readSQLiteColumns(db, tables[i].Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read columns list of %s: %w", tables[i].Name, err)
+		}
+
+		pkCount := 0
+		for j := range columnInfos {
+			if columnInfos[j].PkIndex > 0 {
+				pkCount += 1
+			}
+		}
+
+		for j := range columnInfos {
+			info := columnInfos[j]
+			column := metadata.Column{
+				Ordinal:       info.Cid + 1,
+				Name:          info.Name,
+				Datatype:      normalizeType(info.Type),
+				FormattedType: strings.ToLower(strings.TrimSpace(info.Type)),
+				Nullable:      !info.NotNull && info.PkIndex == 0,
+				DefaultValue:  info.DefaultValue,
+				IsPrimaryKey:  info.PkIndex > 0,
+			}
+
+			// a lone INTEGER PRIMARY KEY is an alias for the rowid, which
+			// sqlite fills in by itself (with or without AUTOINCREMENT)
+			column.IsAutoIncrement = column.IsPrimaryKey && pkCount == 1 &&
+				strings.EqualFold(strings.TrimSpace(info.Type), "integer")
+			if column.IsPrimaryKey && strings.Contains(strings.ToUpper(ddls[i]), "AUTOINCREMENT") {
+				column.IsAutoIncrement = true
+			}
+
+			tables[i].Columns = append(tables[i].Columns, column)
+		}
+
+		// sqlite leaves constraints unnamed, so they get the names PostgreSQL
+		// would give them
+		if pkCount > 0 {
+			pkColumns := make([]string, pkCount)
+			for j := range columnInfos {
+				if columnInfos[j].PkIndex > 0 {
+					pkColumns[columnInfos[j].PkIndex-1] = columnInfos[j].Name
+				}
+			}
+			pkName := metadata.DefaultConstraintName(tables[i].Name, metadata.PrimaryKeyConstraint, pkColumns)
+			tables[i].Constraints = append(tables[i].Constraints, metadata.Constraint{
+				Name:    pkName,
+				Type:    metadata.PrimaryKeyConstraint,
+				Columns: pkColumns,
+			})
+			// the primary key index goes by the name of the constraint, as
+			// sqlite lists none for a rowid alias
+			tables[i].Indexes = append(tables[i].Indexes, metadata.Index{
+				Name:      pkName,
+				Columns:   pkColumns,
+				IsUnique:  true,
+				IsPrimary: true,
+			})
+		}
+
+		// read indexes, and unique constraints along with the indexes backing them
+		indexInfos, err := readSQLiteIndexes(db, tables[i].Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index list of %s: %w", tables[i].Name, err)
+		}
+		for j := range indexInfos {
+			info := indexInfos[j]
+			columns := make([]string, 0)
+			for k := range info.Columns {
+				if info.Columns[k] == nil {
+					columns = nil
+					break
+				}
+				columns = append(columns, *info.Columns[k])
+			}
+			if info.Origin == "pk" || columns == nil || info.Partial {
+				continue
+			}
+			name := info.Name
+			if info.Origin == "u" {
+				name = metadata.DefaultConstraintName(tables[i].Name, metadata.UniqueConstraint, columns)
+				tables[i].Constraints = append(tables[i].Constraints, metadata.Constraint{
+					Name:    name,
+					Type:    metadata.UniqueConstraint,
+					Columns: columns,
+				})
+			}
+			tables[i].Indexes = append(tables[i].Indexes, metadata.Index{
+				Name:     name,
+				Columns:  columns,
+				IsUnique: info.Unique,
+			})
+		}
+
+		// read foreign key references, a row per column of each key
+		fkInfos, err := readSQLiteFkInfo(db, tables[i].Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fk list of %s: %w", tables[i].Name, err)
+		}
+
+		var referenced []SqliteColumnInfo
+		for k := range fkInfos {
+			if k == 0 || fkInfos[k].Id != fkInfos[k-1].Id {
+				tables[i].Constraints = append(tables[i].Constraints, metadata.Constraint{
+					Type:      metadata.ForeignKeyConstraint,
+					RefSchema: "main",
+					RefTable:  fkInfos[k].ReferencedTable,
+					OnDelete:  fkInfos[k].OnDelete,
+					OnUpdate:  fkInfos[k].OnUpdate,
+				})
+				referenced = nil
+			}
+			fk := &tables[i].Constraints[len(tables[i].Constraints)-1]
+
+			refColumn := ""
+			if fkInfos[k].ReferencedColumn != nil {
+				refColumn = *fkInfos[k].ReferencedColumn
+			} else {
+				// a reference without a column list points to the primary key
+				if referenced == nil {
+					referenced, err = readSQLiteColumns(db, fkInfos[k].ReferencedTable)
+					if err != nil {
+						return nil, fmt.Errorf("failed to read columns list of %s: %w", fkInfos[k].ReferencedTable, err)
+					}
+				}
+				for l := range referenced {
+					if referenced[l].PkIndex == fkInfos[k].Seq+1 {
+						refColumn = referenced[l].Name
+					}
+				}
+			}
+			fk.Columns = append(fk.Columns, fkInfos[k].FkColumn)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			fk.Name = metadata.DefaultConstraintName(tables[i].Name, metadata.ForeignKeyConstraint, fk.Columns)
+		}
+
+		// mark single column foreign keys
+		for _, fk := range tables[i].SearchConstraints(metadata.ForeignKeyConstraint) {
+			col := tables[i].SearchColumnByName(fk.Columns[0])
+			if col == nil || len(fk.Columns) != 1 {
+				continue
+			}
+			col.FkTarget = &metadata.ForeignKeyTarget{
+				Schema: fk.RefSchema,
+				Table:  fk.RefTable,
+				Column: fk.RefColumns[0],
+			}
+		}
+	}
+
+	return &metadata.Metadata{
+		Database: config.ConnInfo.Database,
+		Tables:   tables,
+	}, nil
+}
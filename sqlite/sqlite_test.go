@@ -0,0 +1,35 @@
+package sqlite
+
+import "testing"
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		declared   string
+		datatype   string
+		goType     string
+		pythonType string
+	}{
+		{"INTEGER", "integer", "int64", "int"},
+		{"VARCHAR(40)", "varchar", "string", "str"},
+		{"DECIMAL(10, 2)", "decimal", "string", "decimal.Decimal"},
+		{"NUMERIC", "numeric", "string", "decimal.Decimal"},
+		{"MONEY", "numeric", "string", "decimal.Decimal"},
+		{"DOUBLE", "double", "float64", "float"},
+		{"JSON", "json", "json.RawMessage", "str"},
+		{"JSONB", "blob", "[]byte", "bytes"},
+		{"", "blob", "[]byte", "bytes"},
+	}
+	for _, tt := range tests {
+		datatype := normalizeType(tt.declared)
+		if datatype != tt.datatype {
+			t.Errorf("normalizeType(%q) = %q, want %q", tt.declared, datatype, tt.datatype)
+			continue
+		}
+		if got := SQLiteToGolangTypes[datatype]; got != tt.goType {
+			t.Errorf("go type of %s = %q, want %q", tt.declared, got, tt.goType)
+		}
+		if got := SQLiteToPythonTypes[datatype]; got != tt.pythonType {
+			t.Errorf("python type of %s = %q, want %q", tt.declared, got, tt.pythonType)
+		}
+	}
+}